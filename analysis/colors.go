@@ -0,0 +1,55 @@
+package analysis
+
+import (
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+)
+
+// Colorless is the ColorCounts key for colorless cards, which include
+// most lands.
+const Colorless = "C"
+
+// ColorCounts returns the number of mainboard cards of each color, keyed
+// by color letter ("W", "U", "B", "R", "G") or Colorless, and weighted by
+// quantity. A multicolored card counts toward each of its colors, so the
+// counts may add up to more than the mainboard. unknown lists the names
+// of cards missing from corpus, which aren't counted.
+func ColorCounts(d *deck.Deck, corpus *cards.Cards) (counts map[string]int, unknown []string) {
+	counts = map[string]int{}
+	for _, e := range d.Mainboard {
+		card := corpus.LookupNormalized(e.CardName)
+		if card == nil {
+			unknown = append(unknown, e.CardName)
+			continue
+		}
+		colored := false
+		for _, c := range card.Colors {
+			if l := colorLetter(c); l != "" {
+				counts[l] += e.Quantity
+				colored = true
+			}
+		}
+		if !colored {
+			counts[Colorless] += e.Quantity
+		}
+	}
+	return counts, unknown
+}
+
+// colorLetter converts a card color, which mtgjson gives as either a name
+// ("Blue") or a letter ("U"), to its letter.
+func colorLetter(c string) string {
+	switch c {
+	case "White", "W":
+		return "W"
+	case "Blue", "U":
+		return "U"
+	case "Black", "B":
+		return "B"
+	case "Red", "R":
+		return "R"
+	case "Green", "G":
+		return "G"
+	}
+	return ""
+}
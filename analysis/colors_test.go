@@ -0,0 +1,38 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+)
+
+func TestColorCounts(t *testing.T) {
+	corpus := testCorpus(
+		&cards.Card{Name: "Lightning Bolt", Colors: []string{"Red"}},
+		&cards.Card{Name: "Counterspell", Colors: []string{"U"}},
+		&cards.Card{Name: "Izzet Charm", Colors: []string{"Blue", "Red"}},
+		&cards.Card{Name: "Sol Ring"},
+		&cards.Card{Name: "Steam Vents", Colors: []string{}},
+	)
+	d := &deck.Deck{
+		Mainboard: []*deck.Entry{
+			{Quantity: 4, CardName: "Lightning Bolt"},
+			{Quantity: 2, CardName: "counterspell"},
+			{Quantity: 3, CardName: "Izzet Charm"},
+			{Quantity: 1, CardName: "Sol Ring"},
+			{Quantity: 4, CardName: "Steam Vents"},
+			{Quantity: 1, CardName: "Not A Card"},
+		},
+		Sideboard: []*deck.Entry{{Quantity: 4, CardName: "Lightning Bolt"}},
+	}
+
+	counts, unknown := ColorCounts(d, corpus)
+	if want := map[string]int{"R": 7, "U": 5, Colorless: 5}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("got counts %v, want %v", counts, want)
+	}
+	if want := []string{"Not A Card"}; !reflect.DeepEqual(unknown, want) {
+		t.Errorf("got unknown %q, want %q", unknown, want)
+	}
+}
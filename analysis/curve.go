@@ -0,0 +1,46 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+)
+
+// CurveMax is the highest ManaCurve bucket, counting every card with that
+// converted mana cost or more.
+const CurveMax = 7
+
+// ManaCurve returns the number of nonland mainboard cards at each
+// converted mana cost, weighted by quantity. Costs of CurveMax and more
+// share a bucket, and fractional costs are rounded down. unknown lists the
+// names of cards missing from corpus, which aren't counted.
+func ManaCurve(d *deck.Deck, corpus *cards.Cards) (curve map[int]int, unknown []string) {
+	curve = map[int]int{}
+	for _, e := range d.Mainboard {
+		card := corpus.LookupNormalized(e.CardName)
+		if card == nil {
+			unknown = append(unknown, e.CardName)
+			continue
+		}
+		if isLand(card) {
+			continue
+		}
+		cmc := int(card.CMC)
+		if cmc > CurveMax {
+			cmc = CurveMax
+		}
+		curve[cmc] += e.Quantity
+	}
+	return curve, unknown
+}
+
+// isLand reports whether card has the land type.
+func isLand(card *cards.Card) bool {
+	for _, t := range card.Types {
+		if strings.EqualFold(t, "Land") {
+			return true
+		}
+	}
+	return false
+}
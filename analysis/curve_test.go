@@ -0,0 +1,46 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+)
+
+func TestManaCurve(t *testing.T) {
+	corpus := testCorpus(
+		&cards.Card{Name: "Ornithopter", CMC: 0, Types: []string{"Artifact", "Creature"}},
+		&cards.Card{Name: "Llanowar Elves", CMC: 1, Types: []string{"Creature"}},
+		&cards.Card{Name: "Lightning Bolt", CMC: 1, Types: []string{"Instant"}},
+		&cards.Card{Name: "Tarmogoyf", CMC: 2, Types: []string{"Creature"}},
+		&cards.Card{Name: "Primeval Titan", CMC: 6, Types: []string{"Creature"}},
+		&cards.Card{Name: "Emrakul, the Aeons Torn", CMC: 15, Types: []string{"Creature"}},
+		&cards.Card{Name: "Ulamog, the Infinite Gyre", CMC: 11, Types: []string{"Creature"}},
+		&cards.Card{Name: "Forest", Types: []string{"Land"}},
+		&cards.Card{Name: "Dryad Arbor", Types: []string{"Land", "Creature"}},
+	)
+	d := &deck.Deck{
+		Mainboard: []*deck.Entry{
+			{Quantity: 1, CardName: "Ornithopter"},
+			{Quantity: 4, CardName: "Llanowar Elves"},
+			{Quantity: 4, CardName: "Lightning Bolt"},
+			{Quantity: 3, CardName: "Tarmogoyf"},
+			{Quantity: 2, CardName: "Primeval Titan"},
+			{Quantity: 1, CardName: "Emrakul, the Aeons Torn"},
+			{Quantity: 1, CardName: "Ulamog, the Infinite Gyre"},
+			{Quantity: 20, CardName: "Forest"},
+			{Quantity: 1, CardName: "Dryad Arbor"},
+			{Quantity: 2, CardName: "Not A Card"},
+		},
+		Sideboard: []*deck.Entry{{Quantity: 3, CardName: "Tarmogoyf"}},
+	}
+
+	curve, unknown := ManaCurve(d, corpus)
+	if want := map[int]int{0: 1, 1: 8, 2: 3, 6: 2, CurveMax: 2}; !reflect.DeepEqual(curve, want) {
+		t.Errorf("got curve %v, want %v", curve, want)
+	}
+	if want := []string{"Not A Card"}; !reflect.DeepEqual(unknown, want) {
+		t.Errorf("got unknown %q, want %q", unknown, want)
+	}
+}
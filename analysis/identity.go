@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+)
+
+// ColorIdentity returns the union of the color identities of d's
+// commanders as color letters in WUBRG order, such as ["U", "R"]. A
+// colorless identity is empty. Commanders unknown to corpus are skipped.
+func ColorIdentity(d *deck.Deck, corpus *cards.Cards) []string {
+	seen := map[string]bool{}
+	for _, e := range d.Commanders {
+		if card := corpus.LookupNormalized(e.CardName); card != nil {
+			for _, c := range card.ColorIdentity {
+				seen[strings.ToUpper(c)] = true
+			}
+		}
+	}
+	var identity []string
+	for _, c := range []string{"W", "U", "B", "R", "G"} {
+		if seen[c] {
+			identity = append(identity, c)
+		}
+	}
+	return identity
+}
+
+// OffColor returns the mainboard entries of a Commander deck whose color
+// identity falls outside that of its commanders. Other cards unknown to
+// corpus are skipped, as ValidateDeck reports them, but an unknown
+// commander is an error, since its colors can't be allowed.
+func OffColor(d *deck.Deck, corpus *cards.Cards) ([]*deck.Entry, error) {
+	if len(d.Commanders) == 0 {
+		return nil, errors.New("analysis: deck has no commanders")
+	}
+	var unknown []string
+	for _, e := range d.Commanders {
+		if corpus.LookupNormalized(e.CardName) == nil {
+			unknown = append(unknown, e.CardName)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("analysis: unknown commanders: %s", strings.Join(unknown, ", "))
+	}
+	allowed := map[string]bool{}
+	for _, c := range ColorIdentity(d, corpus) {
+		allowed[c] = true
+	}
+
+	var off []*deck.Entry
+	for _, e := range d.Mainboard {
+		card := corpus.LookupNormalized(e.CardName)
+		if card == nil {
+			continue
+		}
+		for _, c := range card.ColorIdentity {
+			if !allowed[strings.ToUpper(c)] {
+				off = append(off, e)
+				break
+			}
+		}
+	}
+	return off, nil
+}
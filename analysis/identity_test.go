@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+)
+
+func TestOffColor(t *testing.T) {
+	corpus := testCorpus(
+		&cards.Card{Name: "Krenko, Mob Boss", ColorIdentity: []string{"R"}},
+		&cards.Card{Name: "Goblin Matron", ColorIdentity: []string{"R"}},
+		&cards.Card{Name: "Sol Ring"},
+		&cards.Card{Name: "Counterspell", ColorIdentity: []string{"U"}},
+		&cards.Card{Name: "Boros Charm", ColorIdentity: []string{"R", "W"}},
+	)
+	commander := &deck.Entry{Quantity: 1, CardName: "Krenko, Mob Boss", Commander: true}
+	d := &deck.Deck{
+		Mainboard: []*deck.Entry{
+			commander,
+			{Quantity: 1, CardName: "Goblin Matron"},
+			{Quantity: 1, CardName: "Sol Ring"},
+			{Quantity: 1, CardName: "Counterspell"},
+			{Quantity: 1, CardName: "Boros Charm"},
+			{Quantity: 1, CardName: "Not A Card"},
+		},
+		Commanders: []*deck.Entry{commander},
+	}
+
+	if got, want := ColorIdentity(d, corpus), []string{"R"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got identity %q, want %q", got, want)
+	}
+	off, err := OffColor(d, corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range off {
+		names = append(names, e.CardName)
+	}
+	if want := []string{"Counterspell", "Boros Charm"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got off-color cards %q, want %q", names, want)
+	}
+
+	if _, err := OffColor(&deck.Deck{Mainboard: d.Mainboard[1:]}, corpus); err == nil {
+		t.Error("got no error for a deck without commanders")
+	}
+
+	// A misspelled partner would otherwise make Boros Charm off-color.
+	partner := &deck.Entry{Quantity: 1, CardName: "Krenko, Tin Strete Kingpin", Commander: true}
+	d.Mainboard = append(d.Mainboard, partner)
+	d.Commanders = append(d.Commanders, partner)
+	off, err = OffColor(d, corpus)
+	if err == nil || !strings.Contains(err.Error(), "Krenko, Tin Strete Kingpin") {
+		t.Errorf("unknown commander: got %v, %v; want an error naming it", off, err)
+	}
+}
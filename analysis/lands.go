@@ -0,0 +1,78 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+)
+
+// LandCounts is the number of lands in a deck, by kind.
+type LandCounts struct {
+	Basic    int // Basic lands, including snow-covered ones.
+	Nonbasic int
+
+	// Modal counts the cards with a land on only some of their faces,
+	// such as modal double-faced cards. They're included in Nonbasic.
+	Modal int
+
+	Total int
+}
+
+// CountLands counts the lands in d's mainboard, weighted by quantity.
+// Lands are recognized by their types or, for cards with several faces,
+// by the type line of each face. unknown lists the names of cards missing
+// from corpus, which aren't counted.
+func CountLands(d *deck.Deck, corpus *cards.Cards) (counts LandCounts, unknown []string) {
+	for _, e := range d.Mainboard {
+		card := corpus.LookupNormalized(e.CardName)
+		if card == nil {
+			unknown = append(unknown, e.CardName)
+			continue
+		}
+		faces := strings.Split(card.Type, " // ")
+		landFaces := 0
+		for _, f := range faces {
+			if hasWord(f, "Land") {
+				landFaces++
+			}
+		}
+		switch {
+		case landFaces == 0 && !isLand(card):
+			continue
+		case isBasic(card):
+			counts.Basic += e.Quantity
+		case landFaces > 0 && landFaces < len(faces):
+			counts.Modal += e.Quantity
+			counts.Nonbasic += e.Quantity
+		default:
+			counts.Nonbasic += e.Quantity
+		}
+		counts.Total += e.Quantity
+	}
+	return counts, unknown
+}
+
+// isBasic reports whether card is a basic land by name or has the basic
+// supertype.
+func isBasic(card *cards.Card) bool {
+	if cards.IsBasicLand(card.Name) {
+		return true
+	}
+	for _, t := range card.SuperTypes {
+		if strings.EqualFold(t, "Basic") {
+			return true
+		}
+	}
+	return strings.HasPrefix(card.Type, "Basic ")
+}
+
+// hasWord reports whether word is one of the space-separated words of s.
+func hasWord(s, word string) bool {
+	for _, w := range strings.Fields(s) {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
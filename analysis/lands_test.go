@@ -0,0 +1,50 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+)
+
+func TestCountLands(t *testing.T) {
+	corpus := testCorpus(
+		&cards.Card{Name: "Forest", Type: "Basic Land — Forest", SuperTypes: []string{"Basic"}, Types: []string{"Land"}},
+		&cards.Card{Name: "Snow-Covered Island", Type: "Basic Snow Land — Island", SuperTypes: []string{"Basic", "Snow"}, Types: []string{"Land"}},
+		// Without supertypes, as in some older data.
+		&cards.Card{Name: "Wastes", Type: "Land", Types: []string{"Land"}},
+		&cards.Card{Name: "Breeding Pool", Type: "Land — Forest Island", Types: []string{"Land"}},
+		&cards.Card{Name: "Dryad Arbor", Type: "Land Creature — Forest Dryad", Types: []string{"Land", "Creature"}},
+		&cards.Card{
+			Name:  "Turntimber Symbiosis // Turntimber, Serpentine Wood",
+			Names: []string{"Turntimber Symbiosis", "Turntimber, Serpentine Wood"},
+			Type:  "Sorcery // Land",
+			Types: []string{"Sorcery", "Land"},
+		},
+		&cards.Card{Name: "Llanowar Elves", Type: "Creature — Elf Druid", Types: []string{"Creature"}},
+		&cards.Card{Name: "Landfall Bear", Type: "Creature — Bear", Types: []string{"Creature"}},
+	)
+	d := &deck.Deck{
+		Mainboard: []*deck.Entry{
+			{Quantity: 10, CardName: "Forest"},
+			{Quantity: 3, CardName: "Snow-Covered Island"},
+			{Quantity: 2, CardName: "Wastes"},
+			{Quantity: 4, CardName: "Breeding Pool"},
+			{Quantity: 1, CardName: "Dryad Arbor"},
+			{Quantity: 2, CardName: "Turntimber Symbiosis"},
+			{Quantity: 4, CardName: "Llanowar Elves"},
+			{Quantity: 4, CardName: "Landfall Bear"},
+			{Quantity: 1, CardName: "Not A Land"},
+		},
+		Sideboard: []*deck.Entry{{Quantity: 4, CardName: "Forest"}},
+	}
+
+	counts, unknown := CountLands(d, corpus)
+	if want := (LandCounts{Basic: 15, Nonbasic: 7, Modal: 2, Total: 22}); counts != want {
+		t.Errorf("got %+v, want %+v", counts, want)
+	}
+	if want := []string{"Not A Land"}; !reflect.DeepEqual(unknown, want) {
+		t.Errorf("got unknown %q, want %q", unknown, want)
+	}
+}
@@ -0,0 +1,62 @@
+// Package analysis checks and summarizes decks using card data
+// from the cards package.
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+)
+
+// Violation is a deck entry that isn't allowed in a format.
+type Violation struct {
+	Entry *deck.Entry
+
+	// Card is the entry's card, or nil if the card name is unknown.
+	Card *cards.Card
+
+	// Legality is the card's legality in the format, such as "Banned"
+	// or "Not Legal". It is empty if Card is nil.
+	Legality string
+}
+
+func (v Violation) String() string {
+	if v.Card == nil {
+		return fmt.Sprintf("%s: unknown card", v.Entry.CardName)
+	}
+	return fmt.Sprintf("%s: %s", v.Card.Name, v.Legality)
+}
+
+// ValidateDeck returns the mainboard and sideboard entries of d that
+// are unknown to corpus or not legal in format, such as "modern" or
+// "commander". Restricted cards are allowed.
+func ValidateDeck(d *deck.Deck, corpus *cards.Cards, format string) ([]Violation, error) {
+	if format == "" {
+		return nil, errors.New("analysis: no format")
+	}
+	if corpus == nil {
+		return nil, errors.New("analysis: no card corpus")
+	}
+
+	var violations []Violation
+	for _, board := range [][]*deck.Entry{d.Mainboard, d.Sideboard} {
+		for _, e := range board {
+			card := corpus.LookupNormalized(e.CardName)
+			if card == nil {
+				violations = append(violations, Violation{Entry: e})
+				continue
+			}
+			switch legality := card.Legality(format); {
+			case strings.EqualFold(legality, "Legal"), strings.EqualFold(legality, "Restricted"):
+			case legality == "":
+				violations = append(violations, Violation{Entry: e, Card: card, Legality: "Not Legal"})
+			default:
+				violations = append(violations, Violation{Entry: e, Card: card, Legality: legality})
+			}
+		}
+	}
+	return violations, nil
+}
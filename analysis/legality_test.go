@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+)
+
+func legalIn(formats ...string) []cards.FormatLegality {
+	var l []cards.FormatLegality
+	for _, f := range formats {
+		l = append(l, cards.FormatLegality{Format: f, Legality: "Legal"})
+	}
+	return l
+}
+
+func testCorpus(cs ...*cards.Card) *cards.Cards {
+	m := map[string]*cards.Card{}
+	for _, c := range cs {
+		m[c.Name] = c
+	}
+	return cards.NewCards(m)
+}
+
+func TestValidateDeck(t *testing.T) {
+	corpus := testCorpus(
+		&cards.Card{Name: "Lightning Bolt", Legalities: legalIn("modern", "legacy")},
+		&cards.Card{Name: "Mountain", Legalities: legalIn("modern", "legacy", "standard")},
+		&cards.Card{Name: "Ancient Den", Legalities: []cards.FormatLegality{
+			{Format: "modern", Legality: "Banned"},
+			{Format: "legacy", Legality: "Legal"},
+		}},
+		&cards.Card{Name: "Black Lotus", Legalities: []cards.FormatLegality{{Format: "vintage", Legality: "Restricted"}}},
+	)
+	d := &deck.Deck{
+		Mainboard: []*deck.Entry{
+			{Quantity: 4, CardName: "Lightning Bolt"},
+			{Quantity: 4, CardName: "Ancient Den"},
+			{Quantity: 12, CardName: "mountain"},
+		},
+		Sideboard: []*deck.Entry{
+			{Quantity: 1, CardName: "Black Lotus"},
+			{Quantity: 1, CardName: "Lightnig Bolt"},
+		},
+	}
+
+	got, err := ValidateDeck(d, corpus, "Modern")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary []string
+	for _, v := range got {
+		summary = append(summary, v.String())
+	}
+	want := []string{"Ancient Den: Banned", "Black Lotus: Not Legal", "Lightnig Bolt: unknown card"}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("got %q, want %q", summary, want)
+	}
+
+	if got, err := ValidateDeck(&deck.Deck{Sideboard: d.Sideboard[:1]}, corpus, "vintage"); err != nil || len(got) != 0 {
+		t.Errorf("got %v, %v for a restricted card; want no violations", got, err)
+	}
+	if _, err := ValidateDeck(d, corpus, ""); err == nil {
+		t.Error("got no error without a format")
+	}
+}
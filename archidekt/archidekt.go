@@ -0,0 +1,169 @@
+// Package archidekt fetches decks from archidekt.com.
+package archidekt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/broady/mtg/deck"
+)
+
+// APIURL is the base URL of Archidekt's public deck API.
+const APIURL = "https://archidekt.com/api/decks/"
+
+func init() {
+	deck.Register("archidekt.com", func(ctx context.Context, deckURL string) (*deck.Deck, error) {
+		return DeckFromURLContext(ctx, nil, deckURL)
+	})
+}
+
+func DeckFromURL(deckURL string) (*deck.Deck, error) {
+	return DeckFromURLContext(context.Background(), nil, deckURL)
+}
+
+// DeckFromURLContext fetches a deck given its URL, such as
+// "https://archidekt.com/decks/<id>/<name>", using ctx for the request. If
+// client is nil, http.DefaultClient is used.
+func DeckFromURLContext(ctx context.Context, client *http.Client, deckURL string) (*deck.Deck, error) {
+	id, err := deckID(deckURL)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, _ := http.NewRequest("GET", APIURL+id+"/", nil)
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", "github.com_broady_mtg")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%q: non-OK response from archidekt: %s", id, resp.Status)
+	}
+
+	d, err := decodeDeck(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	d.Meta.URL = "https://archidekt.com/decks/" + id
+	return d, nil
+}
+
+// deckID returns the numeric ID from an archidekt.com deck URL.
+func deckID(deckURL string) (string, error) {
+	u, err := url.Parse(deckURL)
+	if err != nil {
+		return "", err
+	}
+	if deck.Host(u) != "archidekt.com" {
+		return "", fmt.Errorf("must be an archidekt.com URL; got %q", u.Host)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "decks" || strings.Trim(parts[1], "0123456789") != "" || parts[1] == "" {
+		return "", errors.New("must be a deck URL")
+	}
+	return parts[1], nil
+}
+
+// formats maps Archidekt's numeric deck formats to names.
+var formats = map[int]string{
+	1: "Standard",
+	2: "Modern",
+	3: "Commander",
+	4: "Legacy",
+	5: "Vintage",
+	6: "Pauper",
+}
+
+// apiDeck is a deck as returned by the Archidekt API. Cards are tagged
+// with categories, some of which, like "Sideboard", are left out of the
+// deck itself.
+type apiDeck struct {
+	Name        string
+	Description string
+	DeckFormat  int
+	Owner       struct {
+		Username string
+	}
+	Categories []struct {
+		Name           string
+		IncludedInDeck bool
+	}
+	Cards []struct {
+		Quantity   int
+		Modifier   string // "Normal" or "Foil".
+		Categories []string
+		Card       struct {
+			OracleCard struct {
+				Name string
+			}
+			Edition struct {
+				EditionCode string
+			}
+		}
+	}
+}
+
+func decodeDeck(r io.Reader) (*deck.Deck, error) {
+	var api apiDeck
+	if err := json.NewDecoder(r).Decode(&api); err != nil {
+		return nil, fmt.Errorf("archidekt: decoding deck: %v", err)
+	}
+
+	d := &deck.Deck{
+		Meta: deck.Meta{
+			Name:        api.Name,
+			Author:      api.Owner.Username,
+			Format:      formats[api.DeckFormat],
+			Description: api.Description,
+		},
+	}
+	excluded := map[string]bool{}
+	for _, c := range api.Categories {
+		if !c.IncludedInDeck {
+			excluded[c.Name] = true
+		}
+	}
+
+	for _, c := range api.Cards {
+		entry := &deck.Entry{
+			Quantity: c.Quantity,
+			CardName: c.Card.OracleCard.Name,
+			Printing: strings.ToUpper(c.Card.Edition.EditionCode),
+			Foil:     c.Modifier == "Foil",
+		}
+		board := &d.Mainboard
+		for _, category := range c.Categories {
+			switch category {
+			case "Commander":
+				entry.Commander = true
+			case "Companion":
+				d.Companion = entry
+				board = &d.Sideboard
+			case "Sideboard":
+				board = &d.Sideboard
+			case "Maybeboard":
+				board = &d.Maybeboard
+			}
+		}
+		if len(c.Categories) > 0 && board == &d.Mainboard && excluded[c.Categories[0]] {
+			// Archidekt's first category is the card's primary one.
+			board = &d.Maybeboard
+		}
+		*board = append(*board, entry)
+		if entry.Commander {
+			d.Commanders = append(d.Commanders, entry)
+		}
+	}
+	return d, nil
+}
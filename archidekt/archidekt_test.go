@@ -0,0 +1,59 @@
+package archidekt
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDeckID(t *testing.T) {
+	for _, c := range []struct {
+		url, want string
+	}{
+		{"https://archidekt.com/decks/1234/krenko_tokens", "1234"},
+		{"https://www.archidekt.com/decks/1234", "1234"},
+		{"https://Archidekt.com:443/decks/1234", "1234"},
+		{"https://archidekt.com/decks/krenko", ""},
+		{"https://archidekt.com/user/1234", ""},
+		{"https://www.moxfield.com/decks/1234", ""},
+	} {
+		got, err := deckID(c.url)
+		if got != c.want || (err != nil) != (c.want == "") {
+			t.Errorf("deckID(%q) = %q, %v; want %q", c.url, got, err, c.want)
+		}
+	}
+}
+
+func TestDecodeDeck(t *testing.T) {
+	f, err := os.Open("testdata/deck.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	deck, err := decodeDeck(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	main := 0
+	for _, e := range deck.Mainboard {
+		main += e.Quantity
+	}
+	if main != 32 {
+		t.Errorf("mainboard qty: got %d; want 32", main)
+	}
+	if len(deck.Commanders) != 1 || deck.Commanders[0] != deck.Mainboard[0] {
+		t.Fatalf("got commanders %+v, want Krenko in the mainboard", deck.Commanders)
+	}
+	if c := deck.Commanders[0]; c.CardName != "Krenko, Mob Boss" || c.Printing != "M13" || !c.Foil || !c.Commander {
+		t.Errorf("got commander %+v", c)
+	}
+	if len(deck.Sideboard) != 1 || deck.Sideboard[0].Quantity != 2 {
+		t.Errorf("got sideboard %+v, want 2 Pyroblast", deck.Sideboard)
+	}
+	if len(deck.Maybeboard) != 2 {
+		t.Errorf("got maybeboard %+v, want Goblin Chieftain and Goblin Lackey", deck.Maybeboard)
+	}
+	if deck.Meta.Name != "Krenko Tokens" || deck.Meta.Author != "goblinking" || deck.Meta.Format != "Commander" {
+		t.Errorf("got meta %+v", deck.Meta)
+	}
+}
@@ -2,96 +2,460 @@
 package cards
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
 type Card struct {
-	Name          string
-	Names         []string
-	ManaCost      string
-	CMC           float64
-	Colors        []string
-	ColorIdentity []string
-	Type          string
-	SuperTypes    []string
-	Types         []string
-	SubTypes      []string
-	Rarity        string
-	Text          string
-	Flavor        string
-	Power         string
-	Toughness     string
-	Printings     []string
-	Legalities    []FormatLegality
-	Rulings       []Ruling
-	// Loyalty       int // Nissa has "X".
-	// Only relevant for specific sets.
-	// MultiverseID  int
+	Name          string           `json:"name"`
+	Names         []string         `json:"names,omitempty"`
+	Layout        string           `json:"layout,omitempty"` // e.g. "normal", "split" or "transform", if known.
+	ManaCost      string           `json:"manaCost"`
+	CMC           float64          `json:"cmc"`
+	Colors        []string         `json:"colors"`
+	ColorIdentity []string         `json:"colorIdentity"`
+	Type          string           `json:"type"`
+	SuperTypes    []string         `json:"supertypes"`
+	Types         []string         `json:"types"`
+	SubTypes      []string         `json:"subtypes"`
+	Rarity        string           `json:"rarity,omitempty"` // Not in v5 data; see LoadPrintings.
+	Text          string           `json:"text"`
+	Keywords      []string         `json:"keywords,omitempty"` // e.g. "Flying", "Equip".
+	Flavor        string           `json:"flavor,omitempty"`   // Not in v5 data; see LoadPrintings.
+	Power         string           `json:"power,omitempty"`
+	Toughness     string           `json:"toughness,omitempty"`
+	Printings     []string         `json:"printings"`
+	Legalities    []FormatLegality `json:"legalities"`
+	Rulings       []Ruling         `json:"rulings"`
+	Loyalty       string           `json:"loyalty,omitempty"` // Usually numeric, but Nissa has "X".
+	Artist        string           `json:"artist,omitempty"`  // Not in v5 data; see LoadPrintings.
+
+	// ScryfallOracleID identifies the card on scryfall.com, if known.
+	ScryfallOracleID string `json:"scryfallOracleId,omitempty"`
+
+	// Prints holds the card's printings, if loaded with LoadPrintings.
+	Prints []*Printing `json:"-"`
+}
+
+// ImageURL returns a scryfall.com URL that redirects to an image of the
+// card.
+func (c *Card) ImageURL() string {
+	v := url.Values{}
+	v.Set("exact", c.Name)
+	v.Set("format", "image")
+	return "https://api.scryfall.com/cards/named?" + v.Encode()
+}
+
+// HasImage reports whether the card is known to have an image at
+// ImageURL: it has a ScryfallOracleID, or a printing on Gatherer. Older
+// data and hand-built cards may have neither.
+func (c *Card) HasImage() bool {
+	if c.ScryfallOracleID != "" {
+		return true
+	}
+	for _, p := range c.Prints {
+		if p.MultiverseID != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TextWithoutReminders returns the card's rules text without reminder
+// text, the parenthesized explanations such as "(This creature can block
+// creatures with flying.)".
+func (c *Card) TextWithoutReminders() string {
+	return stripReminders(c.Text)
+}
+
+var reminderText = regexp.MustCompile(`[ \t]*\([^)]*\)`)
+
+func stripReminders(text string) string {
+	return reminderText.ReplaceAllString(text, "")
+}
+
+// UnmarshalJSON decodes a card, accepting Loyalty as either a JSON number
+// or a string.
+func (c *Card) UnmarshalJSON(b []byte) error {
+	type card Card // Avoids recursing into this method.
+	aux := struct {
+		*card
+		Loyalty json.RawMessage `json:"loyalty"`
+	}{card: (*card)(c)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	c.Loyalty = ""
+	if len(aux.Loyalty) == 0 || string(aux.Loyalty) == "null" {
+		return nil
+	}
+	if aux.Loyalty[0] == '"' {
+		return json.Unmarshal(aux.Loyalty, &c.Loyalty)
+	}
+	c.Loyalty = string(aux.Loyalty)
+	return nil
 }
 
 type Ruling struct {
-	Date string
-	Text string
+	Date string `json:"date"` // YYYY-MM-DD
+	Text string `json:"text"`
+}
+
+// LatestRulings returns the card's n most recent rulings, newest first.
+// Rulings with malformed dates sort last. If n is negative, all rulings
+// are returned.
+func (c *Card) LatestRulings(n int) []Ruling {
+	type dated struct {
+		Ruling
+		t  time.Time
+		ok bool
+	}
+	rulings := make([]dated, len(c.Rulings))
+	for i, r := range c.Rulings {
+		t, err := time.Parse("2006-01-02", r.Date)
+		rulings[i] = dated{r, t, err == nil}
+	}
+	sort.SliceStable(rulings, func(i, j int) bool {
+		a, b := rulings[i], rulings[j]
+		if a.ok != b.ok {
+			return a.ok
+		}
+		return a.t.After(b.t)
+	})
+	if n < 0 || n > len(rulings) {
+		n = len(rulings)
+	}
+	out := make([]Ruling, n)
+	for i := range out {
+		out[i] = rulings[i].Ruling
+	}
+	return out
 }
 
 type FormatLegality struct {
-	Format   string
-	Legality string
+	Format   string `json:"format"`
+	Legality string `json:"legality"`
+}
+
+// Legality returns the card's legality in format, such as "Legal" or
+// "Banned", ignoring case in the format name. It returns "" if the card
+// has no legality listed for the format.
+func (c *Card) Legality(format string) string {
+	for _, l := range c.Legalities {
+		if strings.EqualFold(l.Format, format) {
+			return l.Legality
+		}
+	}
+	return ""
+}
+
+// IsBasicLand reports whether name, ignoring case, is one of the five
+// basic lands, their snow-covered versions, or Wastes. Decks may run any
+// number of each.
+func IsBasicLand(name string) bool {
+	name = strings.TrimPrefix(Normalize(name), "snow-covered ")
+	switch name {
+	case "plains", "island", "swamp", "mountain", "forest", "wastes":
+		return true
+	}
+	return false
+}
+
+// NewStore returns a Store that updates itself in the background.
+func NewStore(opts ...Option) *Store {
+	s := newStore()
+	for _, opt := range opts {
+		opt(s)
+	}
+	go s.watch()
+	return s
 }
 
-func NewStore() *Store {
-	s := &Store{
+// An Option configures a Store.
+type Option func(*Store)
+
+// WithUpdateFrequency sets how often the store checks for updates.
+// The default is an hour. Zero disables periodic updates, leaving only
+// the initial one.
+func WithUpdateFrequency(d time.Duration) Option {
+	return func(s *Store) { s.updateFrequency = d }
+}
+
+// WithSourceURL sets the URL cards are fetched from, such as a mirror or
+// a pinned snapshot of the mtgjson data.
+func WithSourceURL(url string) Option {
+	return func(s *Store) { s.sourceURL = url }
+}
+
+// WithCacheFile makes the store keep a copy of the cards at path. On
+// startup, cards are loaded from path (if it exists) before the first
+// fetch, so Cards doesn't have to wait on the network. The file is
+// rewritten after each successful update.
+func WithCacheFile(path string) Option {
+	return func(s *Store) { s.cacheFile = path }
+}
+
+// WithTimeout sets how long each update request may take, including
+// reading the response, overriding the Client's own timeout. The default
+// is five minutes. Zero means no timeout other than the Client's.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Store) { s.timeout = d }
+}
+
+// WithClient sets the Client used to perform updates.
+func WithClient(hc *http.Client) Option {
+	return func(s *Store) { s.Client = hc }
+}
+
+// DefaultSourceURL is where a Store fetches cards from by default.
+// Both the v5 AtomicCards schema and the legacy AllCards schema are
+// supported. AtomicCards has no per-printing data such as rarity; load it
+// with LoadPrintings.
+const DefaultSourceURL = "https://mtgjson.com/api/v5/AtomicCards.json"
+
+// newStore returns a Store that doesn't update itself.
+func newStore() *Store {
+	return &Store{
 		Logger:          log.New(os.Stderr, "cards.Store: ", log.LstdFlags),
+		sourceURL:       DefaultSourceURL,
 		updateFrequency: time.Hour,
+		timeout:         5 * time.Minute,
 		ready:           make(chan bool),
 		closed:          make(chan bool),
 		notifyCh:        make(chan bool),
+		errs:            make(chan error, 10),
 	}
-	go s.watch()
-	return s
 }
 
 // Cards is a corpus of cards.
 //
-// In the future, it will have some methods to allow for search.
+// A Cards made by NewCards is an immutable snapshot: its cards and
+// indexes are never modified, so it can be searched from any number of
+// goroutines. A Store never changes a Cards it has handed out; updates
+// replace it with a new one. The exception is LoadPrintings, which must
+// finish before the Cards is shared.
 type Cards struct {
 	// Map from card name to the card.
 	M map[string]*Card
 
 	// Map from normalized card name to the card.
 	normalized map[string]*Card
+
+	collisions []Collision
+
+	index *index
+
+	subtypesOnce sync.Once
+	subtypes     map[string]int
+}
+
+// A Collision is a normalized name shared by two different cards, so that
+// LookupNormalized finds only one of them.
+type Collision struct {
+	Key  string // The normalized name.
+	Kept *Card  // The card found by the name.
+	Lost *Card  // The card no longer found by the name.
+}
+
+// Collisions returns the normalized names that more than one card
+// shares, sorted by name. Collisions are usually data problems, except
+// when a standalone card shares its name with a face of another card.
+func (c *Cards) Collisions() []Collision {
+	return c.collisions
+}
+
+// SubtypeHistogram returns the number of cards having each subtype, such
+// as "Goblin" or "Aura". A card with several faces is counted once. The
+// histogram is computed on first use; a Store computes a new one for each
+// update, since each update makes a new Cards.
+func (c *Cards) SubtypeHistogram() map[string]int {
+	c.subtypesOnce.Do(func() {
+		c.subtypes = map[string]int{}
+		seen := map[*Card]bool{}
+		for _, card := range c.M {
+			if seen[card] {
+				continue
+			}
+			seen[card] = true
+			for _, st := range card.SubTypes {
+				c.subtypes[st]++
+			}
+		}
+	})
+	h := make(map[string]int, len(c.subtypes))
+	for st, n := range c.subtypes {
+		h[st] = n
+	}
+	return h
+}
+
+// Count returns the number of cards in the corpus.
+func (c *Cards) Count() int {
+	return len(c.M)
 }
 
 // LookupNormalized looks up a card name, ignoring case and other
-// symbols (i.e., "Beck // Call" is equivalent to "beck & CALL")
+// symbols (i.e., "Beck // Call" is equivalent to "beck & CALL"). Names
+// are compared in their Normalize form.
 func (c *Cards) LookupNormalized(cardName string) *Card {
-	return c.normalized[strings.ToLower(normalizeCardName(cardName))]
+	return c.normalized[Normalize(cardName)]
+}
+
+// LookupAll looks up each of names with LookupNormalized. found maps the
+// names that were found, as given, to their cards; missing lists the rest
+// in the order given, without duplicates.
+func (c *Cards) LookupAll(names []string) (found map[string]*Card, missing []string) {
+	found = make(map[string]*Card)
+	missed := map[string]bool{}
+	for _, name := range names {
+		if card := c.LookupNormalized(name); card != nil {
+			found[name] = card
+		} else if !missed[name] {
+			missed[name] = true
+			missing = append(missing, name)
+		}
+	}
+	return found, missing
+}
+
+// LookupFuzzy returns the card whose normalized name is closest to
+// cardName by Levenshtein distance, along with that distance. Callers
+// should decide whether the distance is small enough to trust the match.
+// If there are no cards, LookupFuzzy returns nil and -1.
+func (c *Cards) LookupFuzzy(cardName string) (*Card, int) {
+	name := Normalize(cardName)
+	var (
+		best     *Card
+		bestKey  string
+		bestDist = -1
+	)
+	for key, card := range c.normalized {
+		d := levenshtein(name, key)
+		if bestDist < 0 || d < bestDist || (d == bestDist && key < bestKey) {
+			best, bestKey, bestDist = card, key, d
+		}
+	}
+	return best, bestDist
+}
+
+// levenshtein returns the edit distance between a and b, counted in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// NewCards returns a corpus of the cards in m, keyed by card name. Most
+// callers get a corpus from a Store instead.
+func NewCards(m map[string]*Card) *Cards {
+	c := &Cards{
+		M:          m,
+		normalized: make(map[string]*Card),
+	}
+	c.generateNormalized()
+	c.generateIndex()
+	return c
 }
 
+// generateNormalized indexes each card by its normalized name. Cards
+// with multiple faces (split, flip and double-faced cards) are also
+// indexed by each face name and by the face names joined with "&", "/"
+// and "//". If a face name is also the name of a standalone card, the
+// standalone card wins.
 func (c *Cards) generateNormalized() {
+	index := func(key string, card *Card) {
+		// Legacy data has an entry for each face of a card, all with
+		// the same Names; those aren't different cards.
+		if old := c.normalized[key]; old != nil && old != card && !sameFaces(old, card) {
+			c.collisions = append(c.collisions, Collision{Key: key, Kept: card, Lost: old})
+		}
+		c.normalized[key] = card
+	}
 	for _, card := range c.M {
 		if len(card.Names) != 0 {
-			c.normalized[strings.ToLower(strings.Join(card.Names, " & "))] = card
-			c.normalized[strings.ToLower(strings.Join(card.Names, " / "))] = card
-			c.normalized[strings.ToLower(strings.Join(card.Names, " // "))] = card
+			index(Normalize(strings.Join(card.Names, " & ")), card)
+			index(Normalize(strings.Join(card.Names, " / ")), card)
+			index(Normalize(strings.Join(card.Names, " // ")), card)
+			for _, face := range card.Names {
+				index(Normalize(face), card)
+			}
 		}
-		c.normalized[strings.ToLower(normalizeCardName(card.Name))] = card
 	}
+	for _, card := range c.M {
+		index(Normalize(card.Name), card)
+	}
+	sort.Slice(c.collisions, func(i, j int) bool { return c.collisions[i].Key < c.collisions[j].Key })
 }
 
+// sameFaces reports whether a and b are faces of the same multi-face card.
+func sameFaces(a, b *Card) bool {
+	if len(a.Names) == 0 || len(a.Names) != len(b.Names) {
+		return false
+	}
+	for i := range a.Names {
+		if a.Names[i] != b.Names[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize returns the form of a card name that LookupNormalized
+// compares: "Æ" and "æ" become "Ae" and "ae", the curly apostrophe "’"
+// becomes "'", leading and trailing space is dropped, runs of spaces
+// become one, and the result is lowercased. So "  Æther   Vial" and
+// "aether vial" are both "aether vial". Normalizing twice gives the same
+// result as normalizing once.
+func Normalize(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(normalizeCardName(name)), " "))
+}
+
+// normalizeCardName is Normalize's character replacement, without the
+// lowercasing and space handling, for case-sensitive matching.
 func normalizeCardName(s string) string {
 	s = strings.Replace(s, "Æ", "Ae", -1)
+	s = strings.Replace(s, "æ", "ae", -1)
 	return strings.Replace(s, "’", "'", -1)
 }
 
+// foldName normalizes a card name for forgiving substring searches: it
+// applies normalizeCardName, lowercases, and drops everything but letters
+// and digits, so "jacebeleren" is found in "Jace Beleren".
+func foldName(s string) string {
+	return textFold{}.name(s)
+}
+
 // Store is a card store that periodically updates itself from mtgjson.com.
 type Store struct {
 	// If set, messages from the auto-updater are logged.
@@ -101,14 +465,23 @@ type Store struct {
 	// Used to perform the updates. If unset, http.DefaultClient is used.
 	Client *http.Client
 
+	sourceURL       string
+	cacheFile       string
 	updateFrequency time.Duration
+	timeout         time.Duration
 	closed          chan bool
 	ready           chan bool
 
+	updateMu sync.Mutex // Held while updating.
+
 	mu       sync.RWMutex
 	cards    *Cards
 	etag     string
+	updated  time.Time
 	notifyCh chan bool
+	subs     map[int]func(*Cards) // OnUpdate callbacks, by ID.
+	nextSub  int
+	errs     chan error
 }
 
 // Close prevents future updates.
@@ -122,7 +495,9 @@ func (s *Store) Close() error {
 	return nil
 }
 
-// WaitForUpdate returns a channel that is closed when an update is performed.
+// WaitForUpdate returns a channel that receives the cards when an update
+// is performed and is then closed. It waits even if the store is closed;
+// use WaitForUpdateContext to stop waiting.
 //
 // Sample usage:
 //
@@ -146,7 +521,59 @@ func (s *Store) WaitForUpdate() <-chan *Cards {
 	return ch
 }
 
+// WaitForUpdateContext is like WaitForUpdate, but the channel is closed
+// without sending any cards if ctx is done or the store is closed first.
+// Callers should check for that:
+//
+//	for {
+//		cards, ok := <-s.WaitForUpdateContext(ctx)
+//		if !ok {
+//			return
+//		}
+//		// Perform some re-indexing on cards.
+//	}
+func (s *Store) WaitForUpdateContext(ctx context.Context) <-chan *Cards {
+	s.mu.Lock()
+	notifyCh := s.notifyCh
+	s.mu.Unlock()
+	ch := make(chan *Cards, 1)
+	go func() {
+		defer close(ch)
+		select {
+		case <-notifyCh:
+			ch <- s.Cards()
+		case <-ctx.Done():
+		case <-s.closed:
+		}
+	}()
+	return ch
+}
+
+// OnUpdate registers fn to be called with the new cards after every
+// update, until cancel is called. Each call runs in its own goroutine, so
+// a slow fn doesn't hold up updates, but calls may overlap.
+func (s *Store) OnUpdate(fn func(*Cards)) (cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs == nil {
+		s.subs = map[int]func(*Cards){}
+	}
+	id := s.nextSub
+	s.nextSub++
+	s.subs[id] = fn
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subs, id)
+	}
+}
+
 func (s *Store) watch() {
+	if s.cacheFile != "" {
+		if err := s.loadCache(); err != nil && !os.IsNotExist(err) {
+			s.log().Printf("Could not load cache: %v", err)
+		}
+	}
 	s.maybeUpdate()
 	for {
 		if s.updateFrequency == 0 {
@@ -171,56 +598,169 @@ func (s *Store) log() *log.Logger {
 	return logger
 }
 
+// ErrNotModified is returned by ForceUpdate when the source reports that
+// the cards haven't changed since the last update.
+var ErrNotModified = errors.New("cards: not modified")
+
+// ForceUpdate immediately fetches the cards, rather than waiting for the
+// next periodic update. It returns ErrNotModified if the cards haven't
+// changed. An update with fewer than half as many cards as the current
+// ones is likely corrupt, so it is rejected with an error.
+func (s *Store) ForceUpdate() error {
+	return s.update()
+}
+
+// Errors returns a channel that receives the error from each failed
+// background update. Errors are dropped if the channel's buffer is full,
+// so a supervisor that stops reading never stalls updates.
+func (s *Store) Errors() <-chan error {
+	return s.errs
+}
+
 func (s *Store) maybeUpdate() {
+	if err := s.update(); err != nil && err != ErrNotModified {
+		s.log().Printf("Card update failed: %v", err)
+		select {
+		case s.errs <- err:
+		default:
+		}
+	}
+}
+
+func (s *Store) update() error {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
 	s.mu.Lock()
 	etag := s.etag
 	s.mu.Unlock()
 
-	req, _ := http.NewRequest("GET", "https://mtgjson.com/json/AllCards-x.json", nil)
+	req, err := http.NewRequest("GET", s.sourceURL, nil)
+	if err != nil {
+		return err
+	}
 	req.Header.Set("If-None-Match", etag)
 	req.Header.Set("User-Agent", "github.com_broady_mtg")
+	req.Header.Set("Accept-Encoding", "gzip")
 
 	hc := s.Client
 	if hc == nil {
 		hc = http.DefaultClient
 	}
+	if s.timeout > 0 {
+		withTimeout := *hc
+		withTimeout.Timeout = s.timeout
+		hc = &withTimeout
+	}
 
+	// Retry once if the request fails without a response, such as when
+	// a connection is reset or stalls past the timeout.
 	resp, err := hc.Do(req)
 	if err != nil {
-		s.log().Printf("Could not update: %v", err)
-		return
+		s.log().Printf("Card update failed, retrying: %v", err)
+		resp, err = hc.Do(req)
+	}
+	if err != nil {
+		return fmt.Errorf("could not update: %v", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusNotModified {
-		return
+		return ErrNotModified
 	}
-	b, rerr := ioutil.ReadAll(resp.Body)
+	body, err := decodeBody(resp)
+	if err != nil {
+		return fmt.Errorf("could not read body: %v", err)
+	}
+	b, rerr := ioutil.ReadAll(body)
 	if resp.StatusCode != http.StatusOK {
-		s.log().Printf("Card update failed - HTTP %d, body:\n---\n%s\n---", resp.StatusCode, truncate(b, 1000))
-		return
+		return fmt.Errorf("HTTP %d, body:\n---\n%s\n---", resp.StatusCode, truncate(b, 1000))
 	}
 	if rerr != nil {
-		s.log().Printf("Could not read body: %v", rerr)
-		return
+		return fmt.Errorf("could not read body: %v", rerr)
 	}
 
-	cards := &Cards{
-		M:          make(map[string]*Card),
-		normalized: make(map[string]*Card),
+	m, err := decodeCards(b)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal cards: %v, body:\n---\n%s\n---", err, truncate(b, 1000))
+	}
+	s.mu.Lock()
+	current := s.cards
+	s.mu.Unlock()
+	if current != nil && 2*len(m) < len(current.M) {
+		// A 200 with a truncated body can still decode to a few cards.
+		return fmt.Errorf("suspiciously few cards: got %d, have %d; keeping the current cards", len(m), len(current.M))
+	}
+	etag = resp.Header.Get("Etag")
+	cards := NewCards(m)
+	s.logCollisions(cards)
+	s.setCards(cards, etag)
+	s.mu.Lock()
+	s.updated = time.Now()
+	s.mu.Unlock()
+	s.log().Printf("Card update successful")
+
+	if s.cacheFile != "" {
+		if err := s.saveCache(etag, b); err != nil {
+			s.log().Printf("Could not save cache: %v", err)
+		}
+	}
+	return nil
+}
+
+// decodeBody returns the decompressed response body. The body is
+// gunzipped if the server applied gzip Content-Encoding, and again if the
+// payload itself is gzipped, as with mtgjson's .json.gz files.
+func decodeBody(resp *http.Response) (io.Reader, error) {
+	var r io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		r = zr
+	}
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
 	}
-	if err := json.Unmarshal(b, &cards.M); err != nil {
-		s.log().Printf("Could not unmarshal cards: %v, body:\n---\n%s\n---", err, truncate(b, 1000))
-		return
+	return br, nil
+}
+
+// LastUpdated returns when the cards were last fetched from the source,
+// or the zero time if they haven't been. Loading the cache file doesn't
+// count, nor does a request that found the cards unchanged.
+func (s *Store) LastUpdated() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.updated
+}
+
+// ETag returns the ETag of the current cards, or "" if there is none.
+func (s *Store) ETag() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.etag
+}
+
+func (s *Store) logCollisions(cards *Cards) {
+	for _, c := range cards.Collisions() {
+		s.log().Printf("Card name collision: %q finds %q, not %q", c.Key, c.Kept.Name, c.Lost.Name)
 	}
-	cards.generateNormalized()
+}
+
+// setCards replaces the current cards and notifies any waiters.
+func (s *Store) setCards(cards *Cards, etag string) {
 	s.mu.Lock()
-	s.etag = resp.Header.Get("Etag")
+	s.etag = etag
 	s.cards = cards
-	s.notifyCh = make(chan bool)
 	notify := s.notifyCh
+	s.notifyCh = make(chan bool)
+	for _, fn := range s.subs {
+		go fn(cards)
+	}
 	s.mu.Unlock()
 
-	// Notify.
+	// Wake everyone waiting on the old channel.
 	close(notify)
 
 	select {
@@ -228,8 +768,54 @@ func (s *Store) maybeUpdate() {
 	default:
 		close(s.ready)
 	}
+}
 
-	s.log().Printf("Card update successful")
+// cache is the format of the cache file.
+type cache struct {
+	ETag  string
+	Cards json.RawMessage
+}
+
+func (s *Store) loadCache() error {
+	b, err := ioutil.ReadFile(s.cacheFile)
+	if err != nil {
+		return err
+	}
+	var c cache
+	if err := json.Unmarshal(b, &c); err != nil {
+		return err
+	}
+	m, err := decodeCards(c.Cards)
+	if err != nil {
+		return err
+	}
+	cards := NewCards(m)
+	s.logCollisions(cards)
+	s.setCards(cards, c.ETag)
+	s.log().Printf("Loaded %d cards from cache", len(m))
+	return nil
+}
+
+// saveCache atomically replaces the cache file.
+func (s *Store) saveCache(etag string, body []byte) error {
+	b, err := json.Marshal(cache{ETag: etag, Cards: body})
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(s.cacheFile), filepath.Base(s.cacheFile)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), s.cacheFile)
 }
 
 func truncate(b []byte, n int) []byte {
@@ -239,11 +825,45 @@ func truncate(b []byte, n int) []byte {
 	return b
 }
 
+// Loaded reports whether the store has completed at least one successful
+// update. Unlike Cards, it never blocks.
+func (s *Store) Loaded() bool {
+	select {
+	case <-s.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// Snapshot returns the current cards, or nil if the store hasn't loaded
+// any yet. Unlike Cards, it never blocks. The snapshot is safe to keep
+// and search while the store updates, but doesn't see the updates; call
+// Snapshot again for the latest cards.
+func (s *Store) Snapshot() *Cards {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cards
+}
+
+// Cards returns the current cards, blocking until the first successful
+// update. Like Snapshot, the result doesn't change as the store updates.
 func (s *Store) Cards() *Cards {
-	<-s.ready
+	cards, _ := s.CardsContext(context.Background())
+	return cards
+}
+
+// CardsContext is like Cards, but returns ctx.Err() if ctx is done before
+// the first successful update.
+func (s *Store) CardsContext(ctx context.Context) (*Cards, error) {
+	select {
+	case <-s.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.cards
+	return s.cards, nil
 }
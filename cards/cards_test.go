@@ -1,12 +1,31 @@
 package cards
 
-import "testing"
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
 
 func TestGet(t *testing.T) {
 	s := NewStore()
 	cards := s.Cards()
 	if len(cards.M) < 1000 {
-		t.Fatal("got %d cards; want many", len(cards.M))
+		t.Fatalf("got %d cards; want many", len(cards.M))
 	}
 	shock, ok := cards.M["Shock"]
 	if shock.Type != "Instant" {
@@ -16,3 +35,834 @@ func TestGet(t *testing.T) {
 		t.Fatal("couldn't find Shock")
 	}
 }
+
+func TestUnmarshalLoyalty(t *testing.T) {
+	var m map[string]*Card
+	b := []byte(`{
+		"Jace Beleren": {"name": "Jace Beleren", "loyalty": 3},
+		"Nissa, Steward of Elements": {"name": "Nissa, Steward of Elements", "loyalty": "X"},
+		"Shock": {"name": "Shock", "type": "Instant"}
+	}`)
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"Jace Beleren":               "3",
+		"Nissa, Steward of Elements": "X",
+		"Shock":                      "",
+	} {
+		if got := m[name].Loyalty; got != want {
+			t.Errorf("%s: got loyalty %q, want %q", name, got, want)
+		}
+	}
+	if m["Shock"].Type != "Instant" {
+		t.Errorf("Shock: got type %q, want Instant", m["Shock"].Type)
+	}
+}
+
+func TestLookupFuzzy(t *testing.T) {
+	corpus := NewCards(map[string]*Card{
+		"Lightning Bolt":  {Name: "Lightning Bolt"},
+		"Lightning Helix": {Name: "Lightning Helix"},
+		"Æther Vial":      {Name: "Æther Vial"},
+		"Shock":           {Name: "Shock"},
+	})
+
+	for _, c := range []struct {
+		name string
+		want string
+		dist int
+	}{
+		{"Lightning Bolt", "Lightning Bolt", 0},
+		{"Lightnig Bolt", "Lightning Bolt", 1},
+		{"lightning helx", "Lightning Helix", 1},
+		{"Aether Vail", "Æther Vial", 2},
+		{"shok", "Shock", 1},
+	} {
+		card, dist := corpus.LookupFuzzy(c.name)
+		if card == nil || card.Name != c.want || dist != c.dist {
+			t.Errorf("LookupFuzzy(%q) = %v, %d; want %q, %d", c.name, card, dist, c.want, c.dist)
+		}
+	}
+
+	if _, dist := corpus.LookupFuzzy("zzzzzzzzzzzzzzzzqqqqq"); dist < 10 {
+		t.Errorf("garbage lookup: got distance %d, want a high distance", dist)
+	}
+	if card, dist := NewCards(map[string]*Card{}).LookupFuzzy("Shock"); card != nil || dist != -1 {
+		t.Errorf("empty corpus: got %v, %d; want nil, -1", card, dist)
+	}
+}
+
+func TestLookupAll(t *testing.T) {
+	corpus := NewCards(map[string]*Card{
+		"Lightning Bolt": {Name: "Lightning Bolt"},
+		"Æther Vial":     {Name: "Æther Vial"},
+		"Shock":          {Name: "Shock"},
+	})
+	names := []string{"lightning bolt", "Grizzly Goblins", "aether vial", "Shock", "Not A Card", "Grizzly Goblins"}
+
+	found, missing := corpus.LookupAll(names)
+	got := map[string]string{}
+	for name, card := range found {
+		got[name] = card.Name
+	}
+	wantFound := map[string]string{
+		"lightning bolt": "Lightning Bolt",
+		"aether vial":    "Æther Vial",
+		"Shock":          "Shock",
+	}
+	if !reflect.DeepEqual(got, wantFound) {
+		t.Errorf("got found %q, want %q", got, wantFound)
+	}
+	if want := []string{"Grizzly Goblins", "Not A Card"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("got missing %q, want %q", missing, want)
+	}
+
+	if found, missing := corpus.LookupAll(nil); len(found) != 0 || missing != nil {
+		t.Errorf("no names: got %v, %q; want nothing", found, missing)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for _, c := range []struct{ in, want string }{
+		{"Æther Vial", "aether vial"},
+		{"æther vial", "aether vial"},
+		{"Gaea’s Cradle", "gaea's cradle"},
+		{"Gaea's Cradle", "gaea's cradle"},
+		{"LIGHTNING Bolt", "lightning bolt"},
+		{"  Lightning \t Bolt ", "lightning bolt"},
+		{"Fire // Ice", "fire // ice"},
+		{"", ""},
+	} {
+		got := Normalize(c.in)
+		if got != c.want {
+			t.Errorf("Normalize(%q) = %q, want %q", c.in, got, c.want)
+		}
+		if again := Normalize(got); again != got {
+			t.Errorf("Normalize(%q) = %q, but Normalize(%q) = %q", c.in, got, got, again)
+		}
+	}
+
+	corpus := NewCards(map[string]*Card{"Æther Vial": {Name: "Æther Vial"}})
+	if corpus.LookupNormalized(" æther  VIAL") == nil {
+		t.Error("LookupNormalized didn't find a name that normalizes to the card's")
+	}
+}
+
+func TestHasImage(t *testing.T) {
+	for _, c := range []struct {
+		card *Card
+		want bool
+	}{
+		{&Card{Name: "Shock", ScryfallOracleID: "0f7b9a8a"}, true},
+		{&Card{Name: "Shock", Prints: []*Printing{{Set: "M19"}, {Set: "M10", MultiverseID: 191089}}}, true},
+		{&Card{Name: "Shock", Prints: []*Printing{{Set: "PRM"}}}, false},
+		{&Card{Name: "Shock"}, false},
+	} {
+		if got := c.card.HasImage(); got != c.want {
+			t.Errorf("HasImage() of %+v = %v, want %v", c.card, got, c.want)
+		}
+	}
+}
+
+func TestIsBasicLand(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Island":                true,
+		"forest":                true,
+		"Wastes":                true,
+		"Snow-Covered Swamp":    true,
+		"snow-covered plains":   true,
+		"Snow-Covered Wastes":   true,
+		"Snow-Covered Mountain": true,
+		"Breeding Pool":         false,
+		"Snow-Covered":          false,
+		"Island Fish Jasconius": false,
+		"":                      false,
+	} {
+		if got := IsBasicLand(name); got != want {
+			t.Errorf("IsBasicLand(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+const testPayload = `{
+	"Shock": {"name": "Shock", "type": "Instant", "cmc": 1},
+	"Island": {"name": "Island", "type": "Basic Land — Island"}
+}`
+
+// stubTransport answers every request by calling fn.
+type stubTransport func(*http.Request) *http.Response
+
+func (fn stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req), nil
+}
+
+func stubResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestLoaded(t *testing.T) {
+	s := newStore()
+	s.Logger = nil
+	s.Client = &http.Client{Transport: stubTransport(func(*http.Request) *http.Response {
+		return stubResponse(http.StatusOK, testPayload)
+	})}
+
+	if s.Loaded() {
+		t.Fatal("Loaded before the first update; want not loaded")
+	}
+	s.maybeUpdate()
+	if !s.Loaded() {
+		t.Fatal("not Loaded after a successful update")
+	}
+	if got := s.Cards().Count(); got != 2 {
+		t.Errorf("got %d cards, want 2", got)
+	}
+}
+
+func TestWaitForUpdate(t *testing.T) {
+	s := newStore()
+	s.Logger = nil
+	s.Client = &http.Client{Transport: stubTransport(func(*http.Request) *http.Response {
+		return stubResponse(http.StatusOK, testPayload)
+	})}
+
+	wait := s.WaitForUpdate()
+	s.maybeUpdate()
+	select {
+	case cards := <-wait:
+		if cards.Count() != 2 {
+			t.Errorf("got %d cards, want 2", cards.Count())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitForUpdate didn't fire after an update")
+	}
+}
+
+func TestOnUpdate(t *testing.T) {
+	s := newStore()
+	s.Logger = nil
+	s.Client = &http.Client{Transport: stubTransport(func(*http.Request) *http.Response {
+		return stubResponse(http.StatusOK, testPayload)
+	})}
+
+	updates := make(chan *Cards, 10)
+	cancel := s.OnUpdate(func(c *Cards) { updates <- c })
+	s.maybeUpdate()
+	select {
+	case c := <-updates:
+		if c.Count() != 2 {
+			t.Errorf("got %d cards, want 2", c.Count())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnUpdate callback didn't fire")
+	}
+
+	cancel()
+	s.maybeUpdate()
+	select {
+	case <-updates:
+		t.Error("callback fired after cancel")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWaitForUpdateClosed(t *testing.T) {
+	s := newStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := s.WaitForUpdateContext(ctx)
+	closed := s.WaitForUpdateContext(context.Background())
+	legacy := s.WaitForUpdate()
+
+	cancel()
+	select {
+	case cards, ok := <-cancelled:
+		if ok {
+			t.Errorf("got %v after cancelling, want a closed channel", cards)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitForUpdateContext didn't return after ctx was cancelled")
+	}
+
+	s.Close()
+	select {
+	case cards, ok := <-closed:
+		if ok {
+			t.Errorf("got %v after closing the store, want a closed channel", cards)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitForUpdateContext didn't return after the store was closed")
+	}
+
+	// WaitForUpdate keeps its old behavior of waiting for an update.
+	select {
+	case cards, ok := <-legacy:
+		t.Errorf("WaitForUpdate got %v, %v after the store was closed, want it to keep waiting", cards, ok)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCardsContext(t *testing.T) {
+	s := newStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error)
+	go func() {
+		_, err := s.CardsContext(ctx)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CardsContext blocked despite a cancelled context")
+	}
+}
+
+func TestUpdateFrequency(t *testing.T) {
+	var requests int32
+	s := NewStore(
+		WithUpdateFrequency(10*time.Millisecond),
+		WithClient(&http.Client{Transport: stubTransport(func(*http.Request) *http.Response {
+			atomic.AddInt32(&requests, 1)
+			return stubResponse(http.StatusOK, testPayload)
+		})}),
+	)
+	defer s.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&requests) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d update requests; want at least 2", atomic.LoadInt32(&requests))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSourceURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testPayload))
+	}))
+	defer srv.Close()
+
+	s := NewStore(WithSourceURL(srv.URL), WithUpdateFrequency(0))
+	defer s.Close()
+
+	cards := s.Cards()
+	if got := cards.Count(); got != 2 {
+		t.Errorf("got %d cards, want 2", got)
+	}
+	if shock := cards.M["Shock"]; shock == nil || shock.Type != "Instant" {
+		t.Errorf("got Shock %+v, want an Instant", shock)
+	}
+}
+
+func TestForceUpdate(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) > 1 && r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Etag", `"v1"`)
+		w.Write([]byte(testPayload))
+	}))
+	defer srv.Close()
+
+	s := newStore()
+	s.Logger = nil
+	s.sourceURL = srv.URL
+
+	if err := s.ForceUpdate(); err != nil {
+		t.Fatalf("first update: got error %v, want nil", err)
+	}
+	if got := s.Cards().Count(); got != 2 {
+		t.Errorf("got %d cards, want 2", got)
+	}
+	if err := s.ForceUpdate(); err != ErrNotModified {
+		t.Errorf("second update: got error %v, want %v", err, ErrNotModified)
+	}
+}
+
+func TestUpdateTooFewCards(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) > 1 {
+			// A truncated download that still decodes.
+			w.Header().Set("Etag", `"v2"`)
+			w.Write([]byte(`{"Shock": {"name": "Shock", "type": "Instant"}}`))
+			return
+		}
+		w.Header().Set("Etag", `"v1"`)
+		w.Write([]byte(`{
+			"Shock": {"name": "Shock", "type": "Instant"},
+			"Lightning Bolt": {"name": "Lightning Bolt", "type": "Instant"},
+			"Mountain": {"name": "Mountain", "type": "Basic Land — Mountain"}
+		}`))
+	}))
+	defer srv.Close()
+
+	s := newStore()
+	s.Logger = nil
+	s.sourceURL = srv.URL
+	if err := s.ForceUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ForceUpdate(); err == nil {
+		t.Error("got no error for an update with a third of the cards")
+	}
+	if got := s.Cards().Count(); got != 3 {
+		t.Errorf("got %d cards, want the 3 from before", got)
+	}
+	if got := s.ETag(); got != `"v1"` {
+		t.Errorf("got ETag %q, want %q", got, `"v1"`)
+	}
+}
+
+func TestUpdateTimeout(t *testing.T) {
+	var requests int32
+	release := make(chan bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			// Stall both attempts of the first update.
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Write([]byte(testPayload))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := NewStore(WithSourceURL(srv.URL), WithUpdateFrequency(10*time.Millisecond), WithTimeout(50*time.Millisecond),
+		func(s *Store) { s.Logger = nil })
+	defer s.Close()
+
+	select {
+	case err := <-s.Errors():
+		if !strings.Contains(err.Error(), "could not update") {
+			t.Errorf("got error %v, want a failed update", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stalled update didn't time out")
+	}
+	if got := atomic.LoadInt32(&requests); got < 2 {
+		t.Errorf("got %d requests for the first update, want 2", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cards, err := s.CardsContext(ctx)
+	if err != nil {
+		t.Fatalf("watcher didn't recover: %v", err)
+	}
+	if got := cards.Count(); got != 2 {
+		t.Errorf("got %d cards, want 2", got)
+	}
+}
+
+func TestUpdateRetry(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// Drop the connection without a response.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Write([]byte(testPayload))
+	}))
+	defer srv.Close()
+
+	s := newStore()
+	s.Logger = nil
+	s.sourceURL = srv.URL
+	if err := s.ForceUpdate(); err != nil {
+		t.Fatalf("got error %v, want the retry to succeed", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func TestSnapshotConcurrentUpdates(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		w.Header().Set("Etag", fmt.Sprintf(`"v%d"`, n))
+		fmt.Fprintf(w, `{
+			"Shock": {"name": "Shock", "type": "Instant", "text": "Shock deals 2 damage to any target.", "colors": ["Red"]},
+			"Goblin %d": {"name": "Goblin %d", "type": "Creature — Goblin", "subtypes": ["Goblin"], "colors": ["Red"]}
+		}`, n, n)
+	}))
+	defer srv.Close()
+
+	s := newStore()
+	s.Logger = nil
+	s.sourceURL = srv.URL
+	if s.Snapshot() != nil {
+		t.Fatal("got a snapshot before any update")
+	}
+	if err := s.ForceUpdate(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				snap := s.Snapshot()
+				if got := len(snap.Search(ParseQuery("c:r"))); got != 2 {
+					t.Errorf("got %d red cards, want 2", got)
+				}
+				snap.SubtypeHistogram()
+				snap.LookupNormalized("shock")
+				s.Cards().QuerySorted("t:goblin", SortByName)
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := s.ForceUpdate(); err != nil {
+			t.Error(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestLastUpdated(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) > 1 && r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Etag", `"v1"`)
+		w.Write([]byte(testPayload))
+	}))
+	defer srv.Close()
+
+	s := newStore()
+	s.Logger = nil
+	s.sourceURL = srv.URL
+	if !s.LastUpdated().IsZero() || s.ETag() != "" {
+		t.Fatalf("got LastUpdated %v and ETag %q before any update", s.LastUpdated(), s.ETag())
+	}
+
+	before := time.Now()
+	if err := s.ForceUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	updated := s.LastUpdated()
+	if updated.Before(before) {
+		t.Errorf("got LastUpdated %v, want after %v", updated, before)
+	}
+	if got := s.ETag(); got != `"v1"` {
+		t.Errorf("got ETag %q, want %q", got, `"v1"`)
+	}
+
+	if err := s.ForceUpdate(); err != ErrNotModified {
+		t.Fatalf("got error %v, want %v", err, ErrNotModified)
+	}
+	if got := s.LastUpdated(); !got.Equal(updated) {
+		t.Errorf("got LastUpdated %v after a 304, want %v", got, updated)
+	}
+}
+
+func TestCacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cards.json")
+	cached := `{"ETag": "\"v1\"", "Cards": {"Shock": {"name": "Shock", "type": "Instant"}}}`
+	if err := ioutil.WriteFile(path, []byte(cached), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan bool)
+	defer close(release)
+	var requests int32
+	s := NewStore(
+		WithCacheFile(path),
+		WithUpdateFrequency(0),
+		WithClient(&http.Client{Transport: stubTransport(func(*http.Request) *http.Response {
+			atomic.AddInt32(&requests, 1)
+			<-release
+			return stubResponse(http.StatusNotModified, "")
+		})}),
+	)
+	defer s.Close()
+
+	done := make(chan *Cards)
+	go func() { done <- s.Cards() }()
+	select {
+	case cards := <-done:
+		if cards.M["Shock"] == nil {
+			t.Errorf("cached cards missing Shock: %+v", cards.M)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cards blocked on the network despite a cache file")
+	}
+}
+
+func TestCacheFileSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cards.json")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Etag", `"v2"`)
+		w.Write([]byte(testPayload))
+	}))
+	defer srv.Close()
+
+	s := newStore()
+	s.Logger = nil
+	s.sourceURL = srv.URL
+	s.cacheFile = path
+	if err := s.ForceUpdate(); err != nil {
+		t.Fatal(err)
+	}
+
+	s = newStore()
+	s.Logger = nil
+	s.cacheFile = path
+	if err := s.loadCache(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Cards().Count(); got != 2 {
+		t.Errorf("got %d cached cards, want 2", got)
+	}
+	if s.etag != `"v2"` {
+		t.Errorf("got cached ETag %q, want %q", s.etag, `"v2"`)
+	}
+	matches, _ := filepath.Glob(path + ".tmp*")
+	if len(matches) != 0 {
+		t.Errorf("temporary files left behind: %q", matches)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGzip(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(testPayload))
+	zw.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/AllCards-x.json":
+			if r.Header.Get("Accept-Encoding") != "gzip" {
+				t.Errorf("got Accept-Encoding %q, want gzip", r.Header.Get("Accept-Encoding"))
+			}
+			w.Header().Set("Content-Encoding", "gzip")
+		case "/AllCards-x.json.gz":
+			w.Header().Set("Content-Type", "application/gzip")
+		}
+		w.Write(gz.Bytes())
+	}))
+	defer srv.Close()
+
+	for _, path := range []string{"/AllCards-x.json", "/AllCards-x.json.gz"} {
+		s := newStore()
+		s.Logger = nil
+		s.sourceURL = srv.URL + path
+		if err := s.ForceUpdate(); err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if shock := s.Cards().M["Shock"]; shock == nil || shock.Type != "Instant" {
+			t.Errorf("%s: got Shock %+v, want an Instant", path, shock)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	s := newStore()
+	s.Logger = nil
+	s.sourceURL = srv.URL
+	for i := 0; i < cap(s.errs)+5; i++ {
+		s.maybeUpdate() // Mustn't block once the buffer is full.
+	}
+
+	select {
+	case err := <-s.Errors():
+		if !strings.Contains(err.Error(), "HTTP 500") {
+			t.Errorf("got error %q, want an HTTP 500 error", err)
+		}
+	default:
+		t.Fatal("no error on the Errors channel")
+	}
+}
+
+func TestLookupFaces(t *testing.T) {
+	fireIce := &Card{Name: "Fire // Ice", Names: []string{"Fire", "Ice"}}
+	delver := &Card{
+		Name:  "Delver of Secrets // Insectile Aberration",
+		Names: []string{"Delver of Secrets", "Insectile Aberration"},
+	}
+	// A made-up split card with a face named like a standalone card.
+	shockSplit := &Card{Name: "Shock // Awe", Names: []string{"Shock", "Awe"}}
+	shock := &Card{Name: "Shock"}
+	corpus := NewCards(map[string]*Card{
+		fireIce.Name:    fireIce,
+		delver.Name:     delver,
+		shockSplit.Name: shockSplit,
+		shock.Name:      shock,
+	})
+
+	for name, want := range map[string]*Card{
+		"Fire":                 fireIce,
+		"ice":                  fireIce,
+		"Fire & Ice":           fireIce,
+		"fire // ice":          fireIce,
+		"Delver of Secrets":    delver,
+		"Insectile Aberration": delver,
+		"Awe":                  shockSplit,
+		"Shock":                shock,
+	} {
+		if got := corpus.LookupNormalized(name); got != want {
+			t.Errorf("LookupNormalized(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestImageURL(t *testing.T) {
+	for _, name := range []string{"Shock", "Jace, Vryn's Prodigy", "Fire // Ice", "Æther Vial"} {
+		raw := (&Card{Name: name}).ImageURL()
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Errorf("%q: %v", name, err)
+			continue
+		}
+		if u.Scheme != "https" || u.Host != "api.scryfall.com" || u.Path != "/cards/named" {
+			t.Errorf("%q: got URL %q, want the scryfall named card endpoint", name, raw)
+		}
+		if got := u.Query().Get("exact"); got != name {
+			t.Errorf("%q: got exact=%q in %q", name, got, raw)
+		}
+		if got := u.Query().Get("format"); got != "image" {
+			t.Errorf("%q: got format=%q, want image", name, got)
+		}
+		if strings.ContainsAny(u.RawQuery, " ,'/") {
+			t.Errorf("%q: unescaped characters in %q", name, raw)
+		}
+	}
+}
+
+func TestLatestRulings(t *testing.T) {
+	c := &Card{Rulings: []Ruling{
+		{"2004-10-04", "oldest"},
+		{"not a date", "malformed"},
+		{"2019-05-03", "newest"},
+		{"", "empty"},
+		{"2010-06-15", "middle"},
+	}}
+	texts := func(rulings []Ruling) []string {
+		var s []string
+		for _, r := range rulings {
+			s = append(s, r.Text)
+		}
+		return s
+	}
+
+	for _, tc := range []struct {
+		n    int
+		want []string
+	}{
+		{-1, []string{"newest", "middle", "oldest", "malformed", "empty"}},
+		{2, []string{"newest", "middle"}},
+		{10, []string{"newest", "middle", "oldest", "malformed", "empty"}},
+		{0, nil},
+	} {
+		if got := texts(c.LatestRulings(tc.n)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("LatestRulings(%d) = %q, want %q", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestCollisions(t *testing.T) {
+	corpus := NewCards(map[string]*Card{
+		"Æther Vial":  {Name: "Æther Vial"},
+		"Aether Vial": {Name: "Aether Vial"},
+		"Shock":       {Name: "Shock"},
+	})
+	got := corpus.Collisions()
+	if len(got) != 1 || got[0].Key != "aether vial" {
+		t.Fatalf("got collisions %+v, want one for aether vial", got)
+	}
+	if got[0].Kept == got[0].Lost || corpus.LookupNormalized("aether vial") != got[0].Kept {
+		t.Errorf("got kept %q and lost %q", got[0].Kept.Name, got[0].Lost.Name)
+	}
+
+	var buf bytes.Buffer
+	s := newStore()
+	s.Logger = log.New(&buf, "", 0)
+	s.Client = &http.Client{Transport: stubTransport(func(*http.Request) *http.Response {
+		return stubResponse(http.StatusOK, `{"Æther Vial": {"name": "Æther Vial"}, "Aether Vial": {"name": "Aether Vial"}}`)
+	})}
+	if err := s.ForceUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `collision: "aether vial"`) {
+		t.Errorf("got log %q, want the collision logged", buf.String())
+	}
+
+	// Legacy data lists each face of a card separately.
+	m, err := decodeCards([]byte(`{
+		"Fire": {"name": "Fire", "names": ["Fire", "Ice"], "layout": "split", "manaCost": "{1}{R}"},
+		"Ice": {"name": "Ice", "names": ["Fire", "Ice"], "layout": "split", "manaCost": "{1}{U}"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	corpus = NewCards(m)
+	if got := corpus.Collisions(); len(got) != 0 {
+		t.Errorf("got collisions %+v for the faces of one card, want none", got)
+	}
+	if corpus.LookupNormalized("fire // ice") == nil || corpus.LookupNormalized("ice").Name != "Ice" {
+		t.Error("couldn't look up the legacy split card by its full and face names")
+	}
+}
+
+func TestSubtypeHistogram(t *testing.T) {
+	commando := &Card{Name: "Akki Commando // Goblin Raider", Names: []string{"Akki Commando", "Goblin Raider"}, SubTypes: []string{"Goblin", "Warrior"}}
+	corpus := NewCards(map[string]*Card{
+		"Goblin Guide":      {Name: "Goblin Guide", SubTypes: []string{"Goblin", "Scout"}},
+		"Goblin Piledriver": {Name: "Goblin Piledriver", SubTypes: []string{"Goblin", "Warrior"}},
+		"Llanowar Elves":    {Name: "Llanowar Elves", SubTypes: []string{"Elf", "Druid"}},
+		"Lightning Bolt":    {Name: "Lightning Bolt"},
+		commando.Name:       commando,
+		"Akki Commando":     commando,
+		"Goblin Raider":     commando,
+	})
+	want := map[string]int{"Goblin": 3, "Warrior": 2, "Scout": 1, "Elf": 1, "Druid": 1}
+	if got := corpus.SubtypeHistogram(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	corpus.SubtypeHistogram()["Goblin"] = 0
+	if got := corpus.SubtypeHistogram()["Goblin"]; got != 3 {
+		t.Errorf("after modifying a returned histogram: got %d Goblins, want 3", got)
+	}
+}
@@ -0,0 +1,95 @@
+package cards
+
+import "strings"
+
+// superTypes are the supertypes that can start a type line.
+var superTypes = map[string]bool{"Basic": true, "Legendary": true, "Snow": true, "World": true, "Ongoing": true}
+
+// Faces returns the faces of a split, flip, adventure or double-faced
+// card as pseudo-cards, each with its own name, mana cost, type line and
+// rules text, in the order of Names. Split cards' faces have their own
+// converted mana cost; other faces share the card's. Power, toughness
+// and loyalty are only known for the first face, and colors, legalities
+// and the like are the whole card's. A card with one face is returned
+// as is.
+func (c *Card) Faces() []*Card {
+	if len(c.Names) < 2 {
+		return []*Card{c}
+	}
+	costs := strings.Split(c.ManaCost, " // ")
+	types := strings.Split(c.Type, " // ")
+	texts := strings.Split(c.Text, "\n//\n")
+
+	faces := make([]*Card, len(c.Names))
+	for i, name := range c.Names {
+		f := *c
+		f.Name, f.Names, f.Prints = name, nil, nil
+		f.ManaCost = ""
+		switch {
+		case len(costs) == len(c.Names):
+			f.ManaCost = costs[i]
+		case i == 0:
+			// Without a cost for each face, it is the front face's.
+			f.ManaCost = costs[0]
+		}
+		if len(types) == len(c.Names) {
+			f.Type = types[i]
+			f.SuperTypes, f.Types, f.SubTypes = parseTypeLine(f.Type)
+		}
+		if len(texts) == len(c.Names) {
+			f.Text = texts[i]
+		}
+		f.Keywords = deriveKeywords(f.Text)
+		if c.Layout == "split" || c.Layout == "aftermath" {
+			if syms, err := parseManaCost(f.ManaCost); err == nil {
+				f.CMC = manaValue(syms)
+			}
+		}
+		if i > 0 {
+			f.Power, f.Toughness, f.Loyalty = "", "", ""
+		}
+		faces[i] = &f
+	}
+	return faces
+}
+
+// parseTypeLine splits a type line such as "Legendary Creature — Elf
+// Druid" into its supertypes, types and subtypes.
+func parseTypeLine(line string) (super, types, sub []string) {
+	main, subs := line, ""
+	if i := strings.Index(line, "—"); i >= 0 {
+		main, subs = line[:i], line[i+len("—"):]
+	}
+	for _, w := range strings.Fields(main) {
+		if superTypes[w] {
+			super = append(super, w)
+		} else {
+			types = append(types, w)
+		}
+	}
+	return super, types, strings.Fields(subs)
+}
+
+// manaValue returns the converted mana cost of syms: generic symbols
+// count their amount, variables count zero, hybrid symbols such as "2/W"
+// count their largest part, and every other symbol counts one.
+func manaValue(syms []ManaSymbol) float64 {
+	var v float64
+	for _, s := range syms {
+		if s.IsVariable() {
+			continue
+		}
+		if n, ok := s.Generic(); ok {
+			v += float64(n)
+			continue
+		}
+		max := 1
+		for _, p := range s.parts() {
+			if n, ok := ManaSymbol(p).Generic(); ok && n > max {
+				max = n
+			}
+		}
+		v += float64(max)
+	}
+	return v
+}
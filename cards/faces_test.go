@@ -0,0 +1,88 @@
+package cards
+
+import (
+	"reflect"
+	"testing"
+)
+
+func fireIce() *Card {
+	return &Card{
+		Name:     "Fire // Ice",
+		Names:    []string{"Fire", "Ice"},
+		Layout:   "split",
+		ManaCost: "{1}{R} // {1}{U}",
+		CMC:      4,
+		Colors:   []string{"R", "U"},
+		Type:     "Instant // Instant",
+		Types:    []string{"Instant"},
+		Text:     "Fire deals 2 damage divided as you choose among one or two targets.\n//\nTap target permanent.\nDraw a card.",
+	}
+}
+
+func TestFaces(t *testing.T) {
+	faces := fireIce().Faces()
+	if len(faces) != 2 {
+		t.Fatalf("got %d faces, want 2", len(faces))
+	}
+	for i, want := range []struct {
+		name, cost, text string
+		cmc              float64
+	}{
+		{"Fire", "{1}{R}", "Fire deals 2 damage divided as you choose among one or two targets.", 2},
+		{"Ice", "{1}{U}", "Tap target permanent.\nDraw a card.", 2},
+	} {
+		f := faces[i]
+		if f.Name != want.name || f.ManaCost != want.cost || f.Text != want.text || f.CMC != want.cmc {
+			t.Errorf("face %d: got %q %q %q cmc %v, want %q %q %q cmc %v", i, f.Name, f.ManaCost, f.Text, f.CMC, want.name, want.cost, want.text, want.cmc)
+		}
+		if f.Type != "Instant" || !reflect.DeepEqual(f.Types, []string{"Instant"}) || f.Names != nil {
+			t.Errorf("face %d: got type %q %q, names %q; want an Instant with no names", i, f.Type, f.Types, f.Names)
+		}
+	}
+
+	dfc := &Card{
+		Name:      "Delver of Secrets // Insectile Aberration",
+		Names:     []string{"Delver of Secrets", "Insectile Aberration"},
+		Layout:    "transform",
+		ManaCost:  "{U}",
+		CMC:       1,
+		Type:      "Creature — Human Wizard // Creature — Human Insect",
+		Types:     []string{"Creature"},
+		SubTypes:  []string{"Human", "Wizard", "Insect"},
+		Text:      "At the beginning of your upkeep, look at the top card of your library.\n//\nFlying",
+		Power:     "1",
+		Toughness: "1",
+	}
+	faces = dfc.Faces()
+	back := faces[1]
+	if back.ManaCost != "" || back.CMC != 1 || back.Power != "" {
+		t.Errorf("back face: got cost %q, cmc %v, power %q; want no cost, cmc 1 and unknown power", back.ManaCost, back.CMC, back.Power)
+	}
+	if !reflect.DeepEqual(back.SubTypes, []string{"Human", "Insect"}) || !reflect.DeepEqual(back.Keywords, []string{"Flying"}) {
+		t.Errorf("back face: got subtypes %q, keywords %q", back.SubTypes, back.Keywords)
+	}
+	if front := faces[0]; front.ManaCost != "{U}" || front.Power != "1" {
+		t.Errorf("front face: got cost %q, power %q", front.ManaCost, front.Power)
+	}
+
+	shock := &Card{Name: "Shock"}
+	if got := shock.Faces(); len(got) != 1 || got[0] != shock {
+		t.Errorf("single-faced card: got %v, want the card itself", got)
+	}
+}
+
+func TestSearchExpandFaces(t *testing.T) {
+	fire := fireIce()
+	corpus := NewCards(map[string]*Card{
+		fire.Name: fire, "Fire": fire, "Ice": fire,
+		"Shock": {Name: "Shock", Type: "Instant", Types: []string{"Instant"}},
+	})
+	q := ParseQuery("t:instant")
+	if got := sortedNames(corpus.Search(q)); !reflect.DeepEqual(got, []string{"Fire // Ice", "Shock"}) {
+		t.Errorf("combined: got %q", got)
+	}
+	q.ExpandFaces = true
+	if got := sortedNames(corpus.Search(q)); !reflect.DeepEqual(got, []string{"Fire", "Ice", "Shock"}) {
+		t.Errorf("expanded: got %q", got)
+	}
+}
@@ -0,0 +1,115 @@
+package cards
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SearchResult is the JSON response of a Handler search.
+type SearchResult struct {
+	Total  int     `json:"total"`
+	Offset int     `json:"offset"`
+	Cards  []*Card `json:"cards"`
+}
+
+// defaultLimit is the number of search results returned if the request
+// doesn't say.
+const defaultLimit = 20
+
+// MaxSearchLimit is the most search results Handler returns at once.
+// Larger limits are reduced to it.
+const MaxSearchLimit = 100
+
+// Handler returns an HTTP handler serving the store's cards as JSON:
+//
+//	GET /search?q=t:goblin&offset=0&limit=20  a SearchResult, sorted by name
+//	GET /card/Lightning%20Bolt                a single card, by LookupNormalized
+//
+// Split cards can be looked up as "Fire & Ice", since the mux would clean
+// the slashes out of "Fire // Ice". Searches return at most
+// MaxSearchLimit cards. It responds 503 Service Unavailable until the
+// cards have loaded.
+func (s *Store) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", s.serveSearch)
+	mux.HandleFunc("/card/", s.serveCard)
+	return mux
+}
+
+// serving reports whether the store can serve r, responding with an
+// error if not.
+func (s *Store) serving(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if !s.Loaded() {
+		http.Error(w, "cards not loaded yet", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+func (s *Store) serveSearch(w http.ResponseWriter, r *http.Request) {
+	if !s.serving(w, r) {
+		return
+	}
+	offset, limit, err := pageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, total, err := s.Cards().QueryPage(r.FormValue("q"), offset, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if page == nil {
+		page = []*Card{}
+	}
+	writeJSON(w, SearchResult{Total: total, Offset: offset, Cards: page})
+}
+
+func pageParams(r *http.Request) (offset, limit int, err error) {
+	limit = defaultLimit
+	if v := r.FormValue("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("bad offset %q", v)
+		}
+	}
+	if v := r.FormValue("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			return 0, 0, fmt.Errorf("bad limit %q", v)
+		}
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+	return offset, limit, nil
+}
+
+func (s *Store) serveCard(w http.ResponseWriter, r *http.Request) {
+	if !s.serving(w, r) {
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/card/")
+	c := s.Cards().LookupNormalized(name)
+	if c == nil {
+		http.Error(w, "no card named "+strconv.Quote(name), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, c)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
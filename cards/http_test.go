@@ -0,0 +1,75 @@
+package cards
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	s := newStore()
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	get := func(path string, v interface{}) int {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	if code := get("/search?q=t:goblin", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("before loading: got status %d, want 503", code)
+	}
+
+	m := map[string]*Card{"Island": {Name: "Island", Type: "Basic Land — Island"}}
+	for _, name := range []string{"Goblin Guide", "Goblin Lackey", "Goblin Matron"} {
+		m[name] = &Card{Name: name, Type: "Creature — Goblin"}
+	}
+	s.setCards(NewCards(m), "")
+
+	var res SearchResult
+	if code := get("/search?q=t:goblin&offset=1&limit=1", &res); code != http.StatusOK {
+		t.Fatalf("search: got status %d", code)
+	}
+	if res.Total != 3 || res.Offset != 1 || len(res.Cards) != 1 || res.Cards[0].Name != "Goblin Lackey" {
+		t.Errorf("got %+v, want Goblin Lackey of 3", res)
+	}
+	res = SearchResult{}
+	if code := get("/search?q=t:planeswalker", &res); code != http.StatusOK || res.Total != 0 || res.Cards == nil {
+		t.Errorf("got status %d and %+v, want an empty result", code, res)
+	}
+	if code := get("/search?q=t:goblin&limit=x", nil); code != http.StatusBadRequest {
+		t.Errorf("bad limit: got status %d, want 400", code)
+	}
+	for _, c := range []struct {
+		query string
+		want  int
+	}{
+		{"", defaultLimit},
+		{"limit=5", 5},
+		{"limit=100", MaxSearchLimit},
+		{"limit=1000000", MaxSearchLimit},
+	} {
+		_, limit, err := pageParams(httptest.NewRequest("GET", "/search?"+c.query, nil))
+		if err != nil || limit != c.want {
+			t.Errorf("%q: got limit %d, %v; want %d", c.query, limit, err, c.want)
+		}
+	}
+
+	var c Card
+	if code := get("/card/goblin%20guide", &c); code != http.StatusOK || c.Name != "Goblin Guide" {
+		t.Errorf("got status %d and %+v, want Goblin Guide", code, c)
+	}
+	if code := get("/card/Lightning%20Bolt", nil); code != http.StatusNotFound {
+		t.Errorf("unknown card: got status %d, want 404", code)
+	}
+}
@@ -0,0 +1,156 @@
+package cards
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// index holds inverted indexes over a Cards, used by Search to avoid
+// matching every card against a query.
+type index struct {
+	all []*Card // Every distinct card.
+
+	// colors maps short colors, "m" (multicolored) and "c" (colorless)
+	// to cards.
+	colors map[string][]*Card
+
+	// types and words map each lowercased, whitespace-separated word of
+	// the type line and rules text to the cards that have it. types also
+	// holds the words of Types, SubTypes and SuperTypes, which st: and
+	// super: match and which a card may have without a type line.
+	types, words map[string][]*Card
+
+	// initials maps the first letter or digit of each folded name and
+	// face name, as for name searches, to cards.
+	initials map[rune][]*Card
+}
+
+// generateIndex builds c's inverted indexes.
+func (c *Cards) generateIndex() {
+	ix := &index{
+		colors:   map[string][]*Card{},
+		types:    map[string][]*Card{},
+		words:    map[string][]*Card{},
+		initials: map[rune][]*Card{},
+	}
+	seen := map[*Card]bool{}
+	addWords := func(m map[string][]*Card, s string, card *Card) {
+		added := map[string]bool{}
+		for _, w := range strings.Fields(strings.ToLower(s)) {
+			if !added[w] {
+				m[w] = append(m[w], card)
+				added[w] = true
+			}
+		}
+	}
+	for _, card := range c.M {
+		if seen[card] {
+			continue
+		}
+		seen[card] = true
+		ix.all = append(ix.all, card)
+
+		switch {
+		case len(card.Colors) == 0:
+			ix.colors["c"] = append(ix.colors["c"], card)
+		case len(card.Colors) > 1:
+			ix.colors["m"] = append(ix.colors["m"], card)
+		}
+		for _, color := range card.Colors {
+			if sc := shortColor(color); sc != "" {
+				ix.colors[sc] = append(ix.colors[sc], card)
+			}
+		}
+		typeWords := append([]string{card.Type}, card.Types...)
+		typeWords = append(append(typeWords, card.SubTypes...), card.SuperTypes...)
+		addWords(ix.types, strings.Join(typeWords, " "), card)
+		addWords(ix.words, card.Text, card)
+
+		added := map[rune]bool{}
+		for _, n := range append([]string{card.Name}, card.Names...) {
+			r, _ := utf8.DecodeRuneInString(textFold{}.name(n))
+			if !added[r] {
+				ix.initials[r] = append(ix.initials[r], card)
+				added[r] = true
+			}
+		}
+	}
+	c.index = ix
+}
+
+// candidates returns a subset of the cards that includes every card
+// matching q. A Cards built without NewCards has no index, so all of its
+// cards are candidates.
+func (c *Cards) candidates(q *Query) []*Card {
+	if c.index != nil {
+		return c.index.candidates(q)
+	}
+	var all []*Card
+	for _, card := range c.M {
+		all = append(all, card)
+	}
+	return all
+}
+
+// candidates returns a subset of ix.all that includes every card
+// matching q, using whichever index narrows it down the most. Terms in q
+// match substrings, so a word of a term is looked up as every indexed
+// word containing it.
+func (ix *index) candidates(q *Query) []*Card {
+	best := ix.all
+	consider := func(cards []*Card) {
+		if len(cards) < len(best) {
+			best = cards
+		}
+	}
+	for _, qc := range q.Color {
+		if !strings.HasPrefix(qc, "!") {
+			consider(ix.colors[qc])
+		}
+	}
+	for _, terms := range [][]string{q.Type, q.SubType, q.SuperType} {
+		for _, qt := range terms {
+			for _, w := range strings.Fields(strings.ToLower(qt)) {
+				consider(lookupWord(ix.types, w))
+			}
+		}
+	}
+	for _, qr := range q.Rule {
+		for _, w := range strings.Fields(strings.ToLower(qr)) {
+			consider(lookupWord(ix.words, w))
+		}
+	}
+	// Names that match case- or accent-sensitively also match with the
+	// default folding, so the default is safe to look up.
+	for _, qn := range q.ExactName {
+		r, _ := utf8.DecodeRuneInString(textFold{}.name(qn))
+		consider(ix.initials[r])
+	}
+	for _, qn := range q.NamePrefix {
+		if folded := (textFold{}).name(qn); folded != "" {
+			r, _ := utf8.DecodeRuneInString(folded)
+			consider(ix.initials[r])
+		}
+	}
+	return best
+}
+
+// lookupWord returns the cards having an indexed word that contains w.
+func lookupWord(m map[string][]*Card, w string) []*Card {
+	var (
+		found []*Card
+		seen  = map[*Card]bool{}
+	)
+	for word, cards := range m {
+		if !strings.Contains(word, w) {
+			continue
+		}
+		for _, card := range cards {
+			if !seen[card] {
+				found = append(found, card)
+				seen[card] = true
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,186 @@
+package cards
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// benchCorpus returns n generated cards covering a spread of colors,
+// types and rules text.
+func benchCorpus(n int) *Cards {
+	colors := [][]string{nil, {"White"}, {"Blue"}, {"Black"}, {"Red"}, {"Green"}, {"Blue", "Red"}, {"White", "Black", "Green"}}
+	types := []string{"Creature — Goblin", "Legendary Creature — Elf Druid", "Instant", "Sorcery", "Artifact", "Artifact Creature — Golem", "Enchantment — Aura", "Land"}
+	texts := []string{
+		"Flying",
+		"Draw a card.",
+		"Counter target spell.",
+		"Lightning deals 3 damage to any target.",
+		"{T}: Add {G}.",
+		"Trample, haste",
+		"Enchant creature\nEnchanted creature gets +2/+2.",
+		"",
+	}
+	m := map[string]*Card{}
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("Card %d", i)
+		if i%97 == 0 {
+			name = fmt.Sprintf("Æther Thing %d", i)
+		}
+		m[name] = &Card{
+			Name:   name,
+			Colors: colors[i%len(colors)],
+			Type:   types[(i/3)%len(types)],
+			Text:   texts[(i/7)%len(texts)],
+			CMC:    float64(i % 8),
+		}
+	}
+	// A multi-face card is in the map under each face.
+	fire := &Card{Name: "Fire // Ice", Names: []string{"Fire", "Ice"}, Colors: []string{"Red", "Blue"}, Type: "Instant // Instant", Text: "Fire deals 2 damage divided as you choose."}
+	m[fire.Name], m["Fire"], m["Ice"] = fire, fire, fire
+	return NewCards(m)
+}
+
+// scan is Search without the index.
+func scan(c *Cards, q *Query) []*Card {
+	var match []*Card
+	seen := map[string]bool{}
+	for _, card := range c.M {
+		if q.Match(card) && !seen[card.Name] {
+			match = append(match, card)
+			seen[card.Name] = true
+		}
+	}
+	return match
+}
+
+func sortedNames(cards []*Card) []string {
+	var names []string
+	for _, c := range cards {
+		names = append(names, c.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSearchIndex(t *testing.T) {
+	corpus := benchCorpus(2000)
+	for _, s := range []string{
+		"c:u",
+		"c:m t:creature",
+		"c:c -t:land",
+		"c:!r t:art",
+		"t:legendary",
+		"t:creature st:goblin",
+		"super:legendary",
+		`t:"creature — elf"`,
+		"o:flying",
+		`o:"draw a card"`,
+		"o:damage cmc<=2",
+		"o:ghtn",
+		"o:{t}",
+		"o:nonexistent",
+		`!"Card 12"`,
+		`!"aether thing 97"`,
+		`!"Æther Thing 194"`,
+		"!ice",
+		"card 1",
+		"n^card n$7",
+		"n^ÆTHER",
+		"n^",
+		"t:instant OR t:sorcery",
+		"cmc>=7",
+	} {
+		q := ParseQuery(s)
+		for _, sens := range []bool{false, true} {
+			q.CaseSensitive, q.AccentSensitive = sens, sens
+			got, want := sortedNames(corpus.Search(q)), sortedNames(scan(corpus, q))
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%q (sensitive %v): indexed search got %d cards, want %d", s, sens, len(got), len(want))
+			}
+		}
+	}
+
+	// Cards may have subtypes and supertypes without a type line.
+	krenko := &Card{Name: "Krenko, Mob Boss", SuperTypes: []string{"Legendary"}, Types: []string{"Creature"}, SubTypes: []string{"Goblin", "Warrior"}}
+	corpus.M[krenko.Name] = krenko
+	corpus.generateIndex()
+	for _, s := range []string{"st:goblin", "st:warrior", "super:legendary", "super:legendary st:goblin"} {
+		q := ParseQuery(s)
+		if !q.Match(krenko) {
+			t.Errorf("%q didn't match %s", s, krenko.Name)
+		}
+		got, want := sortedNames(corpus.Search(q)), sortedNames(scan(corpus, q))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: indexed search got %d cards, want %d", s, len(got), len(want))
+		}
+	}
+
+	unindexed := &Cards{M: corpus.M}
+	if got, want := len(unindexed.Search(ParseQuery("c:u"))), len(corpus.Search(ParseQuery("c:u"))); got != want {
+		t.Errorf("without an index: got %d cards, want %d", got, want)
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	corpus := benchCorpus(30000)
+	for _, s := range []string{"c:u", "t:goblin", `o:"counter target"`, "!ice", "cmc>=3"} {
+		q := ParseQuery(s)
+		b.Run(s+"/indexed", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				corpus.Search(q)
+			}
+			b.ReportMetric(float64(len(corpus.candidates(q))), "scanned/op")
+		})
+		b.Run(s+"/scan", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scan(corpus, q)
+			}
+			b.ReportMetric(float64(len(corpus.M)), "scanned/op")
+		})
+	}
+}
+
+// countingContext is a context that is done after Err has been called
+// n times.
+type countingContext struct {
+	context.Context
+	n, calls int
+}
+
+func (c *countingContext) Err() error {
+	c.calls++
+	if c.calls > c.n {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestQueryContext(t *testing.T) {
+	corpus := benchCorpus(2000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got, err := corpus.QueryContext(ctx, "c:u"); err != context.Canceled || got != nil {
+		t.Errorf("cancelled: got %d cards, %v; want none and %v", len(got), err, context.Canceled)
+	}
+
+	// Cancelled partway through, the search stops at the next check.
+	cc := &countingContext{Context: context.Background(), n: 1}
+	if _, err := corpus.SearchContext(cc, ParseQuery("cmc>=0")); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if cc.calls != 2 {
+		t.Errorf("checked the context %d times, want 2", cc.calls)
+	}
+
+	got, err := corpus.QueryContext(context.Background(), "c:u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := corpus.Search(ParseQuery("c:u")); len(got) != len(want) {
+		t.Errorf("got %d cards, want %d", len(got), len(want))
+	}
+}
@@ -0,0 +1,64 @@
+package cards
+
+import "strings"
+
+// knownKeywords are the keyword abilities deriveKeywords recognizes, as
+// mtgjson capitalizes them.
+var knownKeywords = []string{
+	"Affinity", "Annihilator", "Banding", "Bushido", "Cascade", "Changeling",
+	"Convoke", "Cycling", "Deathtouch", "Defender", "Delve", "Double strike",
+	"Echo", "Enchant", "Entwine", "Equip", "Evoke", "Exalted", "Fading",
+	"Fear", "First strike", "Flanking", "Flash", "Flashback", "Flying",
+	"Forestwalk", "Haste", "Hexproof", "Indestructible", "Infect",
+	"Intimidate", "Islandwalk", "Kicker", "Landwalk", "Lifelink", "Madness",
+	"Menace", "Mountainwalk", "Ninjutsu", "Persist", "Plainswalk",
+	"Protection", "Prowess", "Reach", "Rampage", "Shadow", "Shroud",
+	"Suspend", "Swampwalk", "Trample", "Undying", "Vigilance", "Ward",
+	"Wither",
+}
+
+// deriveKeywords returns a best-effort list of the keyword abilities in
+// rules text, for data that doesn't list them. Only lines consisting of
+// keywords, such as "Flying, vigilance" or "Protection from red", count,
+// so "creatures with flying" doesn't.
+func deriveKeywords(text string) []string {
+	var keywords []string
+	for _, line := range strings.Split(stripReminders(text), "\n") {
+		var found []string
+		for _, item := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ';' }) {
+			kw := keywordPrefix(strings.TrimSpace(item))
+			if kw == "" {
+				found = nil
+				break
+			}
+			found = append(found, kw)
+		}
+		keywords = union(keywords, found)
+	}
+	return keywords
+}
+
+// keywordPrefix returns the keyword that item starts with, such as
+// "Equip" for "Equip {2}", or "" if item isn't a keyword ability. The
+// keyword may only be followed by a cost, a number, or a quality as in
+// "Protection from red" or "Enchant creature", so that "Flying creatures
+// can't block" isn't one.
+func keywordPrefix(item string) string {
+	for _, kw := range knownKeywords {
+		if len(item) < len(kw) || !strings.EqualFold(item[:len(kw)], kw) {
+			continue
+		}
+		rest := item[len(kw):]
+		switch {
+		case rest == "",
+			strings.HasPrefix(rest, "—"),
+			strings.HasPrefix(rest, " {"),
+			strings.HasPrefix(rest, " from "),
+			strings.HasPrefix(rest, " for "),
+			len(rest) > 1 && rest[0] == ' ' && rest[1] >= '0' && rest[1] <= '9',
+			kw == "Enchant" && rest[0] == ' ':
+			return kw
+		}
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package cards
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeriveKeywords(t *testing.T) {
+	for _, c := range []struct {
+		text string
+		want []string
+	}{
+		{"Flying", []string{"Flying"}},
+		{"Flying, vigilance", []string{"Flying", "Vigilance"}},
+		{"First strike\nProtection from red", []string{"First strike", "Protection"}},
+		{"Reach (This creature can block creatures with flying.)", []string{"Reach"}},
+		{"Flashback {R}", []string{"Flashback"}},
+		{"Ward—Pay 3 life.", []string{"Ward"}},
+		{"Enchant creature\nEnchanted creature can't block.", []string{"Enchant"}},
+		{"Bushido 1, affinity for artifacts", []string{"Bushido", "Affinity"}},
+		{"Equip {2}\nEquipped creature gets +1/+1 and has flying.", []string{"Equip"}},
+		{"Creatures you control with flying get +1/+1.", nil},
+		{"Flying creatures can't block this turn.", nil},
+		{"", nil},
+	} {
+		if got := deriveKeywords(c.text); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("deriveKeywords(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+}
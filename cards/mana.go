@@ -0,0 +1,198 @@
+package cards
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ManaSymbol is a single symbol of a mana cost, without its braces: a
+// generic amount ("2"), a color ("U"), colorless ("C"), snow ("S"), a
+// variable ("X"), a hybrid ("W/U", "2/W") or a Phyrexian symbol ("U/P").
+type ManaSymbol string
+
+func (m ManaSymbol) parts() []string {
+	return strings.Split(string(m), "/")
+}
+
+// Generic returns the generic mana amount of a symbol such as "2".
+// ok is false for any other symbol.
+func (m ManaSymbol) Generic() (n int, ok bool) {
+	n, err := strconv.Atoi(string(m))
+	return n, err == nil
+}
+
+// Colors returns the colored mana letters in the symbol, e.g. ["W", "U"]
+// for "W/U".
+func (m ManaSymbol) Colors() []string {
+	var colors []string
+	for _, p := range m.parts() {
+		if isColorLetter(p) {
+			colors = append(colors, p)
+		}
+	}
+	return colors
+}
+
+// IsHybrid reports whether the symbol can be paid in more than one way,
+// such as "W/U" or "2/W". Phyrexian symbols are not considered hybrid.
+func (m ManaSymbol) IsHybrid() bool {
+	n := 0
+	for _, p := range m.parts() {
+		if p != "P" {
+			n++
+		}
+	}
+	return n > 1
+}
+
+// IsPhyrexian reports whether the symbol can be paid with life, as with
+// "U/P".
+func (m ManaSymbol) IsPhyrexian() bool {
+	for _, p := range m.parts() {
+		if p == "P" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsVariable reports whether the symbol is "X", "Y" or "Z".
+func (m ManaSymbol) IsVariable() bool {
+	switch m {
+	case "X", "Y", "Z":
+		return true
+	}
+	return false
+}
+
+func isColorLetter(s string) bool {
+	switch s {
+	case "W", "U", "B", "R", "G":
+		return true
+	}
+	return false
+}
+
+func validManaPart(p string) bool {
+	if _, err := strconv.Atoi(p); err == nil {
+		return true
+	}
+	switch p {
+	case "W", "U", "B", "R", "G", "C", "S", "X", "Y", "Z", "P", "H":
+		return true
+	}
+	return false
+}
+
+// ParsedManaCost splits the card's mana cost, such as "{2}{U}{U}", into
+// its symbols. The faces of a split card's cost ("{1}{R} // {1}{U}") are
+// concatenated.
+func (c *Card) ParsedManaCost() ([]ManaSymbol, error) {
+	return parseManaCost(c.ManaCost)
+}
+
+func parseManaCost(cost string) ([]ManaSymbol, error) {
+	var syms []ManaSymbol
+	rest := cost
+	for {
+		rest = strings.TrimLeft(rest, " /")
+		if rest == "" {
+			return syms, nil
+		}
+		if rest[0] != '{' {
+			return nil, fmt.Errorf("mana cost %q: unexpected %q", cost, rest)
+		}
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("mana cost %q: unclosed brace", cost)
+		}
+		sym := ManaSymbol(strings.ToUpper(rest[1:end]))
+		for _, p := range sym.parts() {
+			if !validManaPart(p) {
+				return nil, fmt.Errorf("mana cost %q: unknown symbol {%s}", cost, sym)
+			}
+		}
+		syms = append(syms, sym)
+		rest = rest[end+1:]
+	}
+}
+
+// formatSymbols renders syms as a mana cost, e.g. "{2}{U}".
+func formatSymbols(syms []ManaSymbol) string {
+	var b strings.Builder
+	for _, sym := range syms {
+		b.WriteString("{" + string(sym) + "}")
+	}
+	return b.String()
+}
+
+// ColoredPips counts the colored mana symbols in the card's mana cost by
+// color letter, as for devotion. Hybrid symbols count toward each of
+// their colors. ColoredPips returns nil if the cost can't be parsed.
+func (c *Card) ColoredPips() map[string]int {
+	syms, err := c.ParsedManaCost()
+	if err != nil {
+		return nil
+	}
+	pips := map[string]int{}
+	for _, sym := range syms {
+		for _, color := range sym.Colors() {
+			pips[color]++
+		}
+	}
+	return pips
+}
+
+// manaEmoji maps mana symbol parts to the characters FormatManaCost
+// shows for them.
+var manaEmoji = map[string]string{
+	"W": "⚪", "U": "🔵", "B": "⚫", "R": "🔴", "G": "🟢",
+	"C": "◇", "S": "❄", "P": "Φ", "H": "½",
+	"X": "Ⓧ", "Y": "Ⓨ", "Z": "Ⓩ",
+}
+
+// FormatManaCost renders a mana cost such as "{2}{U}{U}" with emoji and
+// circled numbers, as "②🔵🔵". Hybrid and Phyrexian symbols keep their
+// slash, as "⚪/🔵". Anything it doesn't recognize, such as the " // "
+// between split card costs, is kept as is.
+func FormatManaCost(cost string) string {
+	var out strings.Builder
+	rest := cost
+	for rest != "" {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest, '}')
+		if start < 0 || end < start {
+			out.WriteString(rest)
+			break
+		}
+		out.WriteString(rest[:start])
+		out.WriteString(formatManaSymbol(rest[start : end+1]))
+		rest = rest[end+1:]
+	}
+	return out.String()
+}
+
+// formatManaSymbol renders a braced symbol such as "{W/U}", returning it
+// unchanged if it has unknown parts.
+func formatManaSymbol(braced string) string {
+	var parts []string
+	for _, p := range ManaSymbol(strings.ToUpper(braced[1 : len(braced)-1])).parts() {
+		if n, err := strconv.Atoi(p); err == nil && n >= 0 && n <= 20 {
+			parts = append(parts, circledNumber(n))
+		} else if e, ok := manaEmoji[p]; ok {
+			parts = append(parts, e)
+		} else {
+			return braced
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// circledNumber returns n, from 0 to 20, as a circled number such as "②".
+func circledNumber(n int) string {
+	if n == 0 {
+		return "⓪"
+	}
+	return string(rune('①' + n - 1))
+}
@@ -0,0 +1,84 @@
+package cards
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsedManaCost(t *testing.T) {
+	for _, c := range []struct {
+		cost string
+		want []ManaSymbol
+		pips map[string]int
+	}{
+		{"{2}{U}{U}", []ManaSymbol{"2", "U", "U"}, map[string]int{"U": 2}},
+		{"{W/U}{W/U}{2/B}", []ManaSymbol{"W/U", "W/U", "2/B"}, map[string]int{"W": 2, "U": 2, "B": 1}},
+		{"{1}{U/P}", []ManaSymbol{"1", "U/P"}, map[string]int{"U": 1}},
+		{"{X}{R}", []ManaSymbol{"X", "R"}, map[string]int{"R": 1}},
+		{"{1}{R} // {1}{U}", []ManaSymbol{"1", "R", "1", "U"}, map[string]int{"R": 1, "U": 1}},
+		{"", nil, map[string]int{}},
+	} {
+		card := &Card{ManaCost: c.cost}
+		got, err := card.ParsedManaCost()
+		if err != nil {
+			t.Errorf("%q: %v", c.cost, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: got %q, want %q", c.cost, got, c.want)
+		}
+		if pips := card.ColoredPips(); !reflect.DeepEqual(pips, c.pips) {
+			t.Errorf("%q: got pips %v, want %v", c.cost, pips, c.pips)
+		}
+	}
+
+	for _, cost := range []string{"{2}{U", "2U", "{Q}", "{}"} {
+		if _, err := (&Card{ManaCost: cost}).ParsedManaCost(); err == nil {
+			t.Errorf("%q: got no error, want one", cost)
+		}
+	}
+}
+
+func TestManaSymbol(t *testing.T) {
+	for _, c := range []struct {
+		sym                            ManaSymbol
+		hybrid, phyrexian, variable, g bool
+	}{
+		{"W/U", true, false, false, false},
+		{"2/W", true, false, false, false},
+		{"U/P", false, true, false, false},
+		{"G/U/P", true, true, false, false},
+		{"X", false, false, true, false},
+		{"12", false, false, false, true},
+		{"C", false, false, false, false},
+	} {
+		_, generic := c.sym.Generic()
+		if c.sym.IsHybrid() != c.hybrid || c.sym.IsPhyrexian() != c.phyrexian ||
+			c.sym.IsVariable() != c.variable || generic != c.g {
+			t.Errorf("{%s}: got hybrid %v, phyrexian %v, variable %v, generic %v; want %v, %v, %v, %v",
+				c.sym, c.sym.IsHybrid(), c.sym.IsPhyrexian(), c.sym.IsVariable(), generic,
+				c.hybrid, c.phyrexian, c.variable, c.g)
+		}
+	}
+}
+
+func TestFormatManaCost(t *testing.T) {
+	for _, c := range []struct {
+		cost, want string
+	}{
+		{"{2}{U}{U}", "②🔵🔵"},
+		{"{X}{R}", "Ⓧ🔴"},
+		{"{W/U}{W/U}", "⚪/🔵⚪/🔵"},
+		{"{2/W}", "②/⚪"},
+		{"{G/P}", "🟢/Φ"},
+		{"{0}", "⓪"},
+		{"{15}", "⑮"},
+		{"{1}{R} // {1}{U}", "①🔴 // ①🔵"},
+		{"{Q}{T}", "{Q}{T}"},
+		{"", ""},
+	} {
+		if got := FormatManaCost(c.cost); got != c.want {
+			t.Errorf("FormatManaCost(%q) = %q, want %q", c.cost, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package cards
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// decodeCards decodes mtgjson card data, keyed by card name. It accepts
+// both the v5 AtomicCards schema and the legacy AllCards schema.
+func decodeCards(b []byte) (map[string]*Card, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(b, &top); err != nil {
+		return nil, err
+	}
+	_, hasMeta := top["meta"]
+	data, hasData := top["data"]
+	if !hasMeta || !hasData {
+		var m map[string]*Card
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+		for _, c := range m {
+			if c.Keywords == nil {
+				c.Keywords = deriveKeywords(c.Text)
+			}
+		}
+		return m, nil
+	}
+
+	var atomic map[string][]atomicCard
+	if err := json.Unmarshal(data, &atomic); err != nil {
+		return nil, err
+	}
+	m := make(map[string]*Card, len(atomic))
+	for name, faces := range atomic {
+		if len(faces) == 0 {
+			continue
+		}
+		m[name] = faces[0].card(name, faces)
+	}
+	return m, nil
+}
+
+// atomicCard is a card (or one face of a card) in the mtgjson v5
+// AtomicCards schema.
+type atomicCard struct {
+	Name          string
+	FaceName      string
+	Layout        string
+	ManaCost      string
+	ManaValue     float64
+	Colors        []string
+	ColorIdentity []string
+	Type          string
+	Supertypes    []string
+	Types         []string
+	Subtypes      []string
+	Text          string
+	Keywords      []string
+	Power         string
+	Toughness     string
+	Loyalty       string
+	Printings     []string
+	Legalities    map[string]string
+	Rulings       []Ruling
+	Identifiers   struct {
+		ScryfallOracleID string
+	}
+}
+
+// card converts the faces of a v5 card to a Card. Multi-faced cards are
+// combined into a single Card named after all faces, e.g. "Fire // Ice".
+func (a *atomicCard) card(name string, faces []atomicCard) *Card {
+	c := &Card{
+		Name:          name,
+		Layout:        a.Layout,
+		ManaCost:      a.ManaCost,
+		CMC:           a.ManaValue,
+		Colors:        a.Colors,
+		ColorIdentity: a.ColorIdentity,
+		Type:          a.Type,
+		SuperTypes:    a.Supertypes,
+		Types:         a.Types,
+		SubTypes:      a.Subtypes,
+		Text:          a.Text,
+		Keywords:      a.Keywords,
+		Power:         a.Power,
+		Toughness:     a.Toughness,
+		Loyalty:       a.Loyalty,
+		Printings:     a.Printings,
+		Rulings:       a.Rulings,
+
+		ScryfallOracleID: a.Identifiers.ScryfallOracleID,
+	}
+	for format, legality := range a.Legalities {
+		c.Legalities = append(c.Legalities, FormatLegality{Format: format, Legality: legality})
+	}
+	sort.Slice(c.Legalities, func(i, j int) bool { return c.Legalities[i].Format < c.Legalities[j].Format })
+
+	if a.FaceName == "" || len(faces) < 2 {
+		return c
+	}
+	var costs, types, texts []string
+	seen := map[string]bool{}
+	for _, f := range faces {
+		if seen[f.FaceName] {
+			continue
+		}
+		seen[f.FaceName] = true
+		c.Names = append(c.Names, f.FaceName)
+		if f.ManaCost != "" {
+			costs = append(costs, f.ManaCost)
+		}
+		types = append(types, f.Type)
+		texts = append(texts, f.Text)
+		if f.FaceName != a.FaceName {
+			c.Colors = union(c.Colors, f.Colors)
+			c.SuperTypes = union(c.SuperTypes, f.Supertypes)
+			c.Types = union(c.Types, f.Types)
+			c.SubTypes = union(c.SubTypes, f.Subtypes)
+			c.Keywords = union(c.Keywords, f.Keywords)
+		}
+	}
+	c.ManaCost = strings.Join(costs, " // ")
+	c.Type = strings.Join(types, " // ")
+	c.Text = strings.Join(texts, "\n//\n")
+	return c
+}
+
+// union returns a with any elements of b it lacks appended.
+func union(a, b []string) []string {
+	out := append([]string(nil), a...)
+	for _, s := range b {
+		found := false
+		for _, t := range out {
+			if s == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,198 @@
+package cards
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAtomicCards(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/AtomicCards.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := decodeCards(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cards := NewCards(m)
+	if got := cards.Count(); got != 3 {
+		t.Errorf("got %d cards, want 3", got)
+	}
+
+	shock := cards.LookupNormalized("shock")
+	if shock == nil {
+		t.Fatal("couldn't find Shock")
+	}
+	want := &Card{
+		Name:          "Shock",
+		ManaCost:      "{R}",
+		CMC:           1,
+		Colors:        []string{"R"},
+		ColorIdentity: []string{"R"},
+		Type:          "Instant",
+		SuperTypes:    []string{},
+		Types:         []string{"Instant"},
+		SubTypes:      []string{},
+		Text:          "Shock deals 2 damage to any target.",
+		Printings:     []string{"M19", "STH"},
+		Legalities: []FormatLegality{
+			{"commander", "Legal"},
+			{"modern", "Legal"},
+			{"standard", "Not Legal"},
+		},
+		Rulings: []Ruling{{"2018-07-13", "Shock can target any creature."}},
+
+		ScryfallOracleID: "29d5e2e1-8d9c-4b5b-8a7e-4a8b0d1e9c0f",
+	}
+	if !reflect.DeepEqual(shock, want) {
+		t.Errorf("got Shock\n%+v\nwant\n%+v", shock, want)
+	}
+
+	if nissa := cards.M["Nissa, Steward of Elements"]; nissa.Loyalty != "X" {
+		t.Errorf("got Nissa loyalty %q, want X", nissa.Loyalty)
+	}
+
+	fireIce := cards.LookupNormalized("Fire // Ice")
+	if fireIce == nil {
+		t.Fatal("couldn't find Fire // Ice")
+	}
+	if !reflect.DeepEqual(fireIce.Names, []string{"Fire", "Ice"}) {
+		t.Errorf("got Fire // Ice names %q", fireIce.Names)
+	}
+	if fireIce.ManaCost != "{1}{R} // {1}{U}" {
+		t.Errorf("got Fire // Ice mana cost %q", fireIce.ManaCost)
+	}
+	if !reflect.DeepEqual(fireIce.Colors, []string{"R", "U"}) {
+		t.Errorf("got Fire // Ice colors %q, want R and U", fireIce.Colors)
+	}
+	for _, q := range []string{"f:modern o:tap", "c:ur", "c=ur", "id:ur"} {
+		if !ParseQuery(q).Match(fireIce) {
+			t.Errorf("Fire // Ice doesn't match %q", q)
+		}
+	}
+}
+
+func TestDecodeLegacyCards(t *testing.T) {
+	m, err := decodeCards([]byte(testPayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 2 || m["Shock"].Type != "Instant" {
+		t.Errorf("got %+v, want Shock and Island", m)
+	}
+}
+
+func TestDecodeKeywords(t *testing.T) {
+	m, err := decodeCards([]byte(`{
+		"meta": {"version": "5.1.0"},
+		"data": {"Serra Angel": [{"name": "Serra Angel", "text": "Flying, vigilance", "keywords": ["Flying", "Vigilance"]}]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m["Serra Angel"].Keywords, []string{"Flying", "Vigilance"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got AtomicCards keywords %q, want %q", got, want)
+	}
+
+	// The legacy schema has no keywords, so they're derived from the text.
+	m, err = decodeCards([]byte(`{"Serra Angel": {"name": "Serra Angel", "text": "Flying, vigilance"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m["Serra Angel"].Keywords, []string{"Flying", "Vigilance"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got derived keywords %q, want %q", got, want)
+	}
+}
+
+// atomicFixture returns the cards in testdata/AtomicCards.json.
+func atomicFixture(t *testing.T) *Cards {
+	b, err := ioutil.ReadFile("testdata/AtomicCards.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := decodeCards(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewCards(m)
+}
+
+// loadPrintingsFixture loads testdata/AllPrintings.json into cards.
+func loadPrintingsFixture(t *testing.T, cards *Cards) {
+	f, err := os.Open("testdata/AllPrintings.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := cards.LoadPrintings(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPrintings(t *testing.T) {
+	cards := atomicFixture(t)
+	loadPrintingsFixture(t, cards)
+
+	shock := cards.LookupNormalized("shock")
+	want := []*Printing{
+		{Set: "M19", SetName: "Core Set 2019", Number: "156", Rarity: "common", Artist: "Jason Rainville", MultiverseID: 447340, Card: shock},
+		{Set: "STH", SetName: "Stronghold", Number: "99", Rarity: "common", Artist: "Randy Gallegos", Flavor: "Lightning crackles across the multiverse.", MultiverseID: 5184, Card: shock},
+	}
+	if !reflect.DeepEqual(shock.Prints, want) {
+		t.Errorf("got Shock printings %+v, want %+v", shock.Prints, want)
+	}
+
+	fireIce := cards.LookupNormalized("Fire // Ice")
+	if len(fireIce.Prints) != 2 || fireIce.Prints[0].Set != "APC" || fireIce.Prints[1].Set != "PRM" || fireIce.Prints[0].Card != fireIce {
+		t.Errorf("got Fire // Ice printings %+v, want one from APC and one from PRM", fireIce.Prints)
+	}
+	if nissa := cards.LookupNormalized("Nissa, Steward of Elements"); nissa.Prints != nil {
+		t.Errorf("got Nissa printings %+v, want none", nissa.Prints)
+	}
+
+	if err := cards.LoadPrintings(strings.NewReader(`{"data": [}`)); err == nil {
+		t.Error("got no error for bad JSON")
+	}
+}
+
+func TestQueryPrintingFields(t *testing.T) {
+	cards := atomicFixture(t)
+	for _, q := range []string{"r:rare", "a:gallegos", `ft:"the multiverse"`} {
+		if got := cards.Search(ParseQuery(q)); len(got) != 0 {
+			t.Errorf("%q before loading printings: got %q, want nothing", q, sortedNames(got))
+		}
+	}
+
+	loadPrintingsFixture(t, cards)
+	for _, c := range []struct {
+		q    string
+		want []string
+	}{
+		{"r:rare", []string{"Fire // Ice"}},
+		{"r:u", []string{"Fire // Ice"}},
+		{"r:common", []string{"Shock"}},
+		{"r:mythic", nil},
+		{"a:gallegos", []string{"Shock"}},
+		{"a:RAINVILLE", []string{"Shock"}},
+		{"a:vohwinkel", []string{"Fire // Ice"}},
+		{`ft:"the multiverse"`, []string{"Shock"}},
+		{"ft:damage", nil},
+	} {
+		if got := sortedNames(cards.Search(ParseQuery(c.q))); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: got %q, want %q", c.q, got, c.want)
+		}
+	}
+
+	all := cards.Search(&Query{})
+	sortCards(all, SortByRarity)
+	var got []string
+	for _, c := range all {
+		got = append(got, c.Name)
+	}
+	if want := []string{"Shock", "Fire // Ice", "Nissa, Steward of Elements"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sorted by rarity: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package cards
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AllPrintingsURL is the mtgjson data with every printing of every card,
+// for use with LoadPrintings. It is much larger than DefaultSourceURL.
+const AllPrintingsURL = "https://mtgjson.com/api/v5/AllPrintings.json"
+
+// A Printing is a card as printed in a particular set.
+type Printing struct {
+	Set          string // Set code, e.g. "M19".
+	SetName      string // e.g. "Core Set 2019".
+	Number       string // Collector number, e.g. "156" or "18a".
+	Rarity       string // e.g. "common".
+	Artist       string
+	Flavor       string
+	MultiverseID int // Zero if the printing isn't on Gatherer.
+
+	Card *Card
+}
+
+// rarities, artists and flavors return the card's Rarity, Artist or
+// Flavor if the source data has it, or else the distinct values from its
+// Prints. The v5 AtomicCards data has none of them, so its cards only
+// have them once LoadPrintings has run.
+func (c *Card) rarities() []string {
+	return c.printValues(c.Rarity, func(p *Printing) string { return p.Rarity })
+}
+
+func (c *Card) artists() []string {
+	return c.printValues(c.Artist, func(p *Printing) string { return p.Artist })
+}
+
+func (c *Card) flavors() []string {
+	return c.printValues(c.Flavor, func(p *Printing) string { return p.Flavor })
+}
+
+func (c *Card) printValues(own string, field func(*Printing) string) []string {
+	if own != "" {
+		return []string{own}
+	}
+	var vs []string
+	seen := map[string]bool{}
+	for _, p := range c.Prints {
+		if v := field(p); v != "" && !seen[v] {
+			vs = append(vs, v)
+			seen[v] = true
+		}
+	}
+	return vs
+}
+
+// allPrintings is the mtgjson v5 AllPrintings schema, keyed by set code.
+type allPrintings struct {
+	Data map[string]struct {
+		Code  string
+		Name  string
+		Cards []struct {
+			Name        string
+			Side        string
+			Number      string
+			Rarity      string
+			Artist      string
+			FlavorText  string
+			Identifiers struct {
+				MultiverseID string `json:"multiverseId"`
+			}
+		}
+	}
+}
+
+// LoadPrintings decodes mtgjson AllPrintings data from r and sets the
+// Prints of each card in c, sorted by set code and collector number.
+// Printings of cards that aren't in c are skipped. Since it modifies the
+// cards, it must not be called while c is being searched.
+func (c *Cards) LoadPrintings(r io.Reader) error {
+	var all allPrintings
+	if err := json.NewDecoder(r).Decode(&all); err != nil {
+		return fmt.Errorf("decoding printings: %v", err)
+	}
+	if all.Data == nil {
+		return errors.New("decoding printings: no data")
+	}
+
+	prints := map[*Card][]*Printing{}
+	for code, set := range all.Data {
+		if set.Code != "" {
+			code = set.Code
+		}
+		for _, pc := range set.Cards {
+			if pc.Side != "" && pc.Side != "a" {
+				// Other faces repeat the printing of the first.
+				continue
+			}
+			card := c.LookupNormalized(pc.Name)
+			if card == nil {
+				continue
+			}
+			mid, _ := strconv.Atoi(pc.Identifiers.MultiverseID)
+			prints[card] = append(prints[card], &Printing{
+				Set:          code,
+				SetName:      set.Name,
+				Number:       pc.Number,
+				Rarity:       strings.ToLower(pc.Rarity),
+				Artist:       pc.Artist,
+				Flavor:       pc.FlavorText,
+				MultiverseID: mid,
+				Card:         card,
+			})
+		}
+	}
+	for card, ps := range prints {
+		sort.Slice(ps, func(i, j int) bool {
+			if ps[i].Set != ps[j].Set {
+				return ps[i].Set < ps[j].Set
+			}
+			return ps[i].Number < ps[j].Number
+		})
+		card.Prints = ps
+	}
+	return nil
+}
@@ -1,41 +1,451 @@
 package cards
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Query struct {
 	Name, Rule, Type []string
 
+	// Oracle must appear in the card's rules text without its reminder
+	// text, e.g. `oracle:trample`.
+	Oracle []string
+
+	// NotName, NotRule and NotType must not appear in the card's name,
+	// rules text and type respectively, e.g. "n!island" or "-island",
+	// "-o:defender", and "t!artifact" or "-t:artifact".
+	NotName, NotRule, NotType []string
+
+	// NamePrefix and NameSuffix must start and end the card's name
+	// respectively, ignoring case and punctuation, e.g. "n^jace" or
+	// "n$angel".
+	NamePrefix, NameSuffix []string
+
+	// ExactName must be the card's full name or the name of one of its
+	// faces, ignoring case, e.g. "!island" or `!"lightning bolt"`. The
+	// name words following an unquoted "!" are part of the name, so
+	// "!lightning bolt c:r" is the same as `!"lightning bolt" c:r`.
+	ExactName []string
+
+	// Flavor and Artist must appear in the card's flavor text and artist
+	// respectively, e.g. `ft:"the multiverse"` or "a:rebecca". Like
+	// Rarity, they match any printing of a card with loaded printings.
+	Flavor, Artist []string
+
+	// Regexp holds patterns that must match the rules text, e.g.
+	// `re:"deals \d+ damage"`.
+	Regexp []*regexp.Regexp
+
 	// Color may be "w", "u", "b", "r", "g", "m" (multicolored),
-	// or any of the previous characters with a "!" prefix (not).
+	// "c" (colorless), or any of the previous characters with a "!"
+	// prefix (not). Colorless refers to the card's colors, so most lands
+	// are colorless regardless of their color identity.
 	Color []string
+
+	// ColorCount constrains the number of colors, e.g. "colors>=2".
+	ColorCount []StatConstraint
+
+	// SubType and SuperType must each appear in the card's SubTypes and
+	// SuperTypes respectively, e.g. "st:goblin" or "super:legendary".
+	SubType, SuperType []string
+
+	// Keyword lists keyword abilities the card must have, ignoring case,
+	// e.g. "kw:flying" or `kw:"first strike"`. Unlike "o:flying", it
+	// doesn't match cards that only mention the keyword.
+	Keyword []string
+
+	// Is lists predicates the card must satisfy, e.g. "is:vanilla". See
+	// isPredicates for the names.
+	Is []string
+
+	// ColorSet compares the card's colors as a set, e.g. "c=ur" (exactly
+	// blue and red), "c<=w" (white or colorless) or "c>=wu" (at least
+	// white and blue).
+	ColorSet []ColorSetConstraint
+
+	// ColorOverlap constrains how many of a set of colors the card has,
+	// e.g. "c>=2of:wub" (at least two of white, blue and black).
+	ColorOverlap []ColorOverlapConstraint
+
+	// CMC constrains the converted mana cost, e.g. "cmc>=4".
+	CMC []CMCConstraint
+
+	// Mana compares mana cost symbols, e.g. "mana:{U}" (at least one blue
+	// pip) or "mana={2}{U}" (exactly that cost, in any order).
+	Mana []ManaConstraint
+
+	// Power and Toughness constrain a creature's stats, e.g. "pow>=5".
+	// Cards with non-numeric stats such as "*" never match.
+	Power, Toughness []StatConstraint
+
+	// Loyalty constrains a planeswalker's starting loyalty, e.g. "loy>=4".
+	// Non-numeric loyalties such as "X" never match.
+	Loyalty []StatConstraint
+
+	// Devotion constrains the number of colored pips in the mana cost,
+	// e.g. "dev>=3:u". Hybrid symbols count toward each of their colors.
+	Devotion []DevotionConstraint
+
+	// Prints constrains the number of sets the card has been printed in,
+	// e.g. "prints=1" or "prints>=10".
+	Prints []StatConstraint
+
+	// Sets lists set codes the card must have been printed in, e.g.
+	// "s:mh2".
+	Sets []string
+
+	// Rarity may be "common", "uncommon", "rare", "mythic" or "special".
+	// A card matches if it has any of the listed rarities. Cards decoded
+	// from v5 data have rarities only once LoadPrintings has run, and then
+	// match the rarity of any of their printings.
+	Rarity []string
+
+	// Format lists formats the card must be legal in, e.g. "commander".
+	// Banned lists formats the card must be banned in.
+	Format, Banned []string
+
+	// Legal holds lists of formats from terms like "legal:standard,modern".
+	// The card must be legal in at least one format of every list.
+	Legal [][]string
+
+	// ColorIdentity may be "w", "u", "b", "r", "g", "c" (colorless), or
+	// any of those with a "!" prefix (not). The card's color identity
+	// must fall within the listed colors, as for a Commander deck, and
+	// must not include any negated color.
+	ColorIdentity []string
+
+	// Or holds groups of alternatives, such as "c:r OR c:g". A card must
+	// match at least one query in every group.
+	Or [][]*Query
+
+	// CaseSensitive and AccentSensitive turn off the lowercasing and the
+	// "Æ" to "Ae" normalization, respectively, when matching names, rules
+	// text, types, flavor text and artists. They apply to the
+	// alternatives in Or as well.
+	CaseSensitive, AccentSensitive bool
+
+	// ExpandFaces makes searches return each face of a matching
+	// multi-face card as its own card, as from Card.Faces, rather than
+	// the combined card.
+	ExpandFaces bool
+}
+
+// StatConstraint is a comparison against a numeric card statistic, such
+// as power or toughness.
+type StatConstraint struct {
+	Op    string // One of "=", "!=", "<", "<=", ">", ">=".
+	Value int
+}
+
+// Match reports whether stat satisfies the constraint. Non-numeric stats
+// such as "*" or "1+*" never match.
+func (sc StatConstraint) Match(stat string) bool {
+	n, err := strconv.Atoi(stat)
+	if err != nil {
+		return false
+	}
+	return compare(sc.Op, float64(n), float64(sc.Value))
+}
+
+// DevotionConstraint is a comparison against the number of mana symbols
+// of one color in a card's mana cost.
+type DevotionConstraint struct {
+	Color string // Short color, e.g. "u".
+	StatConstraint
+}
+
+// Match reports whether the card satisfies the constraint.
+func (dc DevotionConstraint) Match(c *Card) bool {
+	n := c.ColoredPips()[strings.ToUpper(dc.Color)]
+	return compare(dc.Op, float64(n), float64(dc.Value))
+}
+
+// ColorOverlapConstraint is a comparison against the number of colors a
+// card shares with a set of colors.
+type ColorOverlapConstraint struct {
+	Colors string // Short colors, e.g. "wub".
+	StatConstraint
+}
+
+// Match reports whether colors, given as mtgjson color names, satisfy
+// the constraint.
+func (oc ColorOverlapConstraint) Match(colors []string) bool {
+	n := 0
+	for _, c := range colors {
+		if sc := shortColor(c); sc != "" && strings.Contains(oc.Colors, sc) {
+			n++
+		}
+	}
+	return compare(oc.Op, float64(n), float64(oc.Value))
+}
+
+// ManaConstraint compares a card's mana cost against a set of symbols.
+type ManaConstraint struct {
+	Symbols []ManaSymbol
+	Exact   bool // Whether the cost must be exactly Symbols, not contain them.
+}
+
+// Match reports whether the symbols of a mana cost satisfy the constraint.
+// Symbol order doesn't matter, but repeated symbols must be repeated in
+// the cost.
+func (mc ManaConstraint) Match(syms []ManaSymbol) bool {
+	if mc.Exact && len(syms) != len(mc.Symbols) {
+		return false
+	}
+	count := map[ManaSymbol]int{}
+	for _, sym := range syms {
+		count[sym]++
+	}
+	for _, sym := range mc.Symbols {
+		if count[sym] == 0 {
+			return false
+		}
+		count[sym]--
+	}
+	return true
+}
+
+// ColorSetConstraint compares a card's colors against a set of colors.
+type ColorSetConstraint struct {
+	Op     string // One of "=", "<=", ">=".
+	Colors string // Short colors, e.g. "ur".
+}
+
+// Match reports whether colors, given as mtgjson color names, satisfy
+// the constraint.
+func (cc ColorSetConstraint) Match(colors []string) bool {
+	have := map[string]bool{}
+	for _, c := range colors {
+		have[shortColor(c)] = true
+	}
+	want := map[string]bool{}
+	for _, c := range cc.Colors {
+		want[string(c)] = true
+	}
+	subset := func(a, b map[string]bool) bool {
+		for c := range a {
+			if !b[c] {
+				return false
+			}
+		}
+		return true
+	}
+	switch cc.Op {
+	case "=":
+		return subset(have, want) && subset(want, have)
+	case "<=":
+		return subset(have, want)
+	case ">=":
+		return subset(want, have)
+	}
+	return false
+}
+
+// CMCConstraint is a comparison against a card's converted mana cost.
+type CMCConstraint struct {
+	Op    string // One of "=", "!=", "<", "<=", ">", ">=".
+	Value float64
+}
+
+// Match reports whether cmc satisfies the constraint.
+func (cc CMCConstraint) Match(cmc float64) bool {
+	return compare(cc.Op, cmc, cc.Value)
 }
 
 func (q *Query) Match(c *Card) bool {
+	return q.match(c, textFold{q.CaseSensitive, q.AccentSensitive}, nil)
+}
+
+// MatchExplain is like Match, but also returns a human-readable reason
+// for each satisfied term, such as `rule text contains "flying"`, in the
+// order they were checked. There are no reasons if c doesn't match.
+func (q *Query) MatchExplain(c *Card) (bool, []string) {
+	why := []string{}
+	if !q.match(c, textFold{q.CaseSensitive, q.AccentSensitive}, &why) {
+		return false, nil
+	}
+	return true, why
+}
+
+// match reports whether c matches q. If why is non-nil, a reason is
+// appended to it for each satisfied term.
+func (q *Query) match(c *Card, f textFold, why *[]string) bool {
+	because := func(format string, args ...interface{}) {
+		*why = append(*why, fmt.Sprintf(format, args...))
+	}
+	name := f.name(c.Name)
 	for _, qn := range q.Name {
-		if strings.Contains(strings.ToLower(c.Name), qn) {
-			continue
+		if !strings.Contains(name, f.name(qn)) {
+			debugf("name %q", qn)
+			return false
+		}
+		if why != nil {
+			because("name contains %q", qn)
+		}
+	}
+	for _, qn := range q.NamePrefix {
+		if !strings.HasPrefix(name, f.name(qn)) {
+			debugf("name ^%q", qn)
+			return false
+		}
+		if why != nil {
+			because("name starts with %q", qn)
+		}
+	}
+	for _, qn := range q.NameSuffix {
+		if !strings.HasSuffix(name, f.name(qn)) {
+			debugf("name $%q", qn)
+			return false
+		}
+		if why != nil {
+			because("name ends with %q", qn)
+		}
+	}
+	for _, qn := range q.ExactName {
+		if !c.hasName(qn, f) {
+			debugf("exact name %q", qn)
+			return false
+		}
+		if why != nil {
+			because("name is %q", qn)
 		}
-		debugf("name %q", qn)
-		return false
 	}
 	for _, qr := range q.Rule {
-		if !strings.Contains(strings.ToLower(c.Text), qr) {
+		if !strings.Contains(f.text(c.Text), f.text(qr)) {
 			debugf("rule %q", qr)
 			return false
 		}
+		if why != nil {
+			because("rule text contains %q", qr)
+		}
+	}
+	if len(q.Oracle) > 0 {
+		text := f.text(c.TextWithoutReminders())
+		for _, qo := range q.Oracle {
+			if !strings.Contains(text, f.text(qo)) {
+				debugf("oracle %q", qo)
+				return false
+			}
+			if why != nil {
+				because("rule text without reminders contains %q", qo)
+			}
+		}
+	}
+	for _, qn := range q.NotName {
+		if strings.Contains(name, f.name(qn)) {
+			debugf("name !%q", qn)
+			return false
+		}
+		if why != nil {
+			because("name doesn't contain %q", qn)
+		}
+	}
+	for _, qr := range q.NotRule {
+		if strings.Contains(f.text(c.Text), f.text(qr)) {
+			debugf("rule !%q", qr)
+			return false
+		}
+		if why != nil {
+			because("rule text doesn't contain %q", qr)
+		}
+	}
+	for _, qt := range q.NotType {
+		if strings.Contains(f.text(c.Type), f.text(qt)) {
+			debugf("type !%q", qt)
+			return false
+		}
+		if why != nil {
+			because("type doesn't contain %q", qt)
+		}
+	}
+	for _, qf := range q.Flavor {
+		if !containsText(c.flavors(), qf, f) {
+			debugf("flavor %q", qf)
+			return false
+		}
+		if why != nil {
+			because("flavor text contains %q", qf)
+		}
+	}
+	for _, qa := range q.Artist {
+		if !containsText(c.artists(), qa, f) {
+			debugf("artist %q", qa)
+			return false
+		}
+		if why != nil {
+			because("artist contains %q", qa)
+		}
+	}
+	for _, re := range q.Regexp {
+		if !re.MatchString(c.Text) {
+			debugf("regexp %q", re)
+			return false
+		}
+		if why != nil {
+			because("rule text matches %q", re)
+		}
 	}
 	for _, qt := range q.Type {
-		if strings.Contains(strings.ToLower(c.Type), qt) {
-			continue
+		if !strings.Contains(f.text(c.Type), f.text(qt)) {
+			debugf("type %q", qt)
+			return false
+		}
+		if why != nil {
+			because("type contains %q", qt)
+		}
+	}
+	for _, qs := range q.SubType {
+		if !containsFold(c.SubTypes, qs) {
+			debugf("subtype %q", qs)
+			return false
+		}
+		if why != nil {
+			because("subtypes include %q", qs)
+		}
+	}
+	for _, qs := range q.SuperType {
+		if !containsFold(c.SuperTypes, qs) {
+			debugf("supertype %q", qs)
+			return false
+		}
+		if why != nil {
+			because("supertypes include %q", qs)
+		}
+	}
+	for _, qi := range q.Is {
+		f, ok := isPredicates[qi]
+		if !ok {
+			debugf("unknown is %q", qi)
+			return false
+		}
+		if !f(c) {
+			debugf("is %q", qi)
+			return false
+		}
+		if why != nil {
+			because("is %s", qi)
+		}
+	}
+	for _, qk := range q.Keyword {
+		if !containsFold(c.Keywords, qk) {
+			debugf("keyword %q", qk)
+			return false
+		}
+		if why != nil {
+			because("keywords include %q", qk)
 		}
-		debugf("type %q", qt)
-		return false
 	}
-Color:
 	for _, qc := range q.Color {
 		if len(qc) == 0 {
 			continue
@@ -45,101 +455,1123 @@ Color:
 			not = true
 			qc = qc[1:]
 		}
-		if qc == "m" {
-			if len(c.Colors) > 1 {
-				if not {
-					debugf("color !%q", qc)
-					return false
+		if hasColor(c, qc) == not {
+			if not {
+				debugf("color !%q", qc)
+			} else {
+				debugf("color %q", qc)
+			}
+			return false
+		}
+		if why != nil {
+			if not {
+				because("color isn't %s", colorLabel(qc))
+			} else {
+				because("color is %s", colorLabel(qc))
+			}
+		}
+	}
+Or:
+	for _, alts := range q.Or {
+		for _, alt := range alts {
+			if why == nil {
+				if alt.match(c, f, nil) {
+					continue Or
 				}
 				continue
 			}
+			// Only the reasons of the matching alternative count.
+			altWhy := []string{}
+			if alt.match(c, f, &altWhy) {
+				*why = append(*why, altWhy...)
+				continue Or
+			}
+		}
+		debugf("or %d alternatives", len(alts))
+		return false
+	}
+	for _, qc := range q.ColorCount {
+		if !compare(qc.Op, float64(len(c.Colors)), float64(qc.Value)) {
+			debugf("colors %s%d", qc.Op, qc.Value)
+			return false
+		}
+		if why != nil {
+			because("number of colors %s %d", qc.Op, qc.Value)
 		}
-		for _, c := range c.Colors {
-			if shortColor(c) == qc {
-				if not {
-					debugf("color !%q", qc)
-					return false
+	}
+	for _, qc := range q.ColorSet {
+		if !qc.Match(c.Colors) {
+			debugf("color %s%s", qc.Op, qc.Colors)
+			return false
+		}
+		if why != nil {
+			because("colors %s %s", qc.Op, qc.Colors)
+		}
+	}
+	for _, qo := range q.ColorOverlap {
+		if !qo.Match(c.Colors) {
+			debugf("color %s%dof:%s", qo.Op, qo.Value, qo.Colors)
+			return false
+		}
+		if why != nil {
+			because("%s %d of colors %s", qo.Op, qo.Value, qo.Colors)
+		}
+	}
+	for _, qc := range q.CMC {
+		if !qc.Match(c.CMC) {
+			debugf("cmc %s%v", qc.Op, qc.Value)
+			return false
+		}
+		if why != nil {
+			because("cmc %s %v", qc.Op, qc.Value)
+		}
+	}
+	if len(q.Mana) > 0 {
+		syms, err := c.ParsedManaCost()
+		for _, qm := range q.Mana {
+			if err != nil || !qm.Match(syms) {
+				debugf("mana %v", qm.Symbols)
+				return false
+			}
+			if why != nil {
+				if qm.Exact {
+					because("mana cost is %s", formatSymbols(qm.Symbols))
+				} else {
+					because("mana cost contains %s", formatSymbols(qm.Symbols))
 				}
-				continue Color
 			}
 		}
-		if not {
-			continue
+	}
+	for _, qp := range q.Power {
+		if !qp.Match(c.Power) {
+			debugf("pow %s%d", qp.Op, qp.Value)
+			return false
+		}
+		if why != nil {
+			because("power %s %d", qp.Op, qp.Value)
+		}
+	}
+	for _, qt := range q.Toughness {
+		if !qt.Match(c.Toughness) {
+			debugf("tou %s%d", qt.Op, qt.Value)
+			return false
+		}
+		if why != nil {
+			because("toughness %s %d", qt.Op, qt.Value)
+		}
+	}
+	for _, ql := range q.Loyalty {
+		if !ql.Match(c.Loyalty) {
+			debugf("loy %s%d", ql.Op, ql.Value)
+			return false
+		}
+		if why != nil {
+			because("loyalty %s %d", ql.Op, ql.Value)
+		}
+	}
+	for _, qd := range q.Devotion {
+		if !qd.Match(c) {
+			debugf("dev %s%d:%s", qd.Op, qd.Value, qd.Color)
+			return false
+		}
+		if why != nil {
+			because("devotion to %s %s %d", qd.Color, qd.Op, qd.Value)
+		}
+	}
+	for _, qp := range q.Prints {
+		if !compare(qp.Op, float64(len(c.Printings)), float64(qp.Value)) {
+			debugf("prints %s%d", qp.Op, qp.Value)
+			return false
+		}
+		if why != nil {
+			because("number of printings %s %d", qp.Op, qp.Value)
+		}
+	}
+	for _, qs := range q.Sets {
+		if !containsFold(c.Printings, qs) {
+			debugf("set %q", qs)
+			return false
+		}
+		if why != nil {
+			because("printed in %s", strings.ToUpper(qs))
+		}
+	}
+	if len(q.Rarity) > 0 {
+		r, ok := matchRarity(c.rarities(), q.Rarity)
+		if !ok {
+			debugf("rarity %q", q.Rarity)
+			return false
+		}
+		if why != nil {
+			because("rarity is %s", r)
+		}
+	}
+	if len(q.ColorIdentity) > 0 {
+		if !matchIdentity(c.ColorIdentity, q.ColorIdentity) {
+			debugf("identity %q", q.ColorIdentity)
+			return false
+		}
+		if why != nil {
+			because("color identity within %s", strings.Join(q.ColorIdentity, ","))
+		}
+	}
+	for _, qf := range q.Format {
+		if !c.hasLegality(qf, "Legal") {
+			debugf("format %q", qf)
+			return false
+		}
+		if why != nil {
+			because("legal in %s", qf)
+		}
+	}
+	for _, ql := range q.Legal {
+		if !c.legalInAny(ql) {
+			debugf("legal %q", ql)
+			return false
+		}
+		if why != nil {
+			because("legal in one of %s", strings.Join(ql, ", "))
+		}
+	}
+	for _, qb := range q.Banned {
+		if !c.hasLegality(qb, "Banned") {
+			debugf("banned %q", qb)
+			return false
+		}
+		if why != nil {
+			because("banned in %s", qb)
+		}
+	}
+
+	return true
+}
+
+// isPredicates are the predicates of "is:" terms.
+var isPredicates = map[string]func(*Card) bool{
+	// Both faces on one side of the card, like "Fire // Ice". Without a
+	// layout, any card with several faces counts.
+	"split": func(c *Card) bool {
+		if c.Layout != "" {
+			return c.Layout == "split"
+		}
+		return len(c.Names) > 1
+	},
+	// Double-faced, like "Delver of Secrets // Insectile Aberration".
+	// Without a layout, any card with several faces counts.
+	"dfc": func(c *Card) bool {
+		switch c.Layout {
+		case "":
+			return len(c.Names) > 1
+		case "transform", "modal_dfc", "double_faced_token", "reversible_card":
+			return true
 		}
-		debugf("color %q", qc)
 		return false
+	},
+	// No rules text, like "Grizzly Bears".
+	"vanilla": func(c *Card) bool {
+		return c.Text == ""
+	},
+	"permanent": func(c *Card) bool {
+		return !c.hasType("Instant") && !c.hasType("Sorcery")
+	},
+	"spell": func(c *Card) bool {
+		return c.hasType("Instant") || c.hasType("Sorcery")
+	},
+	// A legendary creature, or a card that says it can be your commander.
+	"commander": func(c *Card) bool {
+		return c.hasType("Legendary") && c.hasType("Creature") ||
+			strings.Contains(strings.ToLower(c.Text), "can be your commander")
+	},
+}
+
+// hasType reports whether c has the type or supertype t, falling back on
+// the words of its type line if it has neither list.
+func (c *Card) hasType(t string) bool {
+	if len(c.Types) > 0 || len(c.SuperTypes) > 0 {
+		return containsFold(c.Types, t) || containsFold(c.SuperTypes, t)
+	}
+	return containsFold(strings.Fields(c.Type), t)
+}
+
+// hasColor reports whether c has the color qc, which may also be "m"
+// (multicolored) or "c" (colorless).
+func hasColor(c *Card, qc string) bool {
+	switch qc {
+	case "m":
+		return len(c.Colors) > 1
+	case "c":
+		return len(c.Colors) == 0
 	}
+	for _, color := range c.Colors {
+		if shortColor(color) == qc {
+			return true
+		}
+	}
+	return false
+}
+
+// colorLabel names a query color for MatchExplain.
+func colorLabel(qc string) string {
+	switch qc {
+	case "w":
+		return "white"
+	case "u":
+		return "blue"
+	case "b":
+		return "black"
+	case "r":
+		return "red"
+	case "g":
+		return "green"
+	case "m":
+		return "multicolored"
+	case "c":
+		return "colorless"
+	}
+	return qc
+}
+
+func compare(op string, a, b float64) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+	return false
+}
 
+func matchIdentity(identity, want []string) bool {
+	allowed := map[string]bool{}
+	bounded := false
+	for _, qc := range want {
+		if strings.HasPrefix(qc, "!") {
+			continue
+		}
+		bounded = true
+		allowed[qc] = true
+	}
+	for _, id := range identity {
+		id = identityColor(id)
+		if bounded && !allowed[id] {
+			return false
+		}
+		for _, qc := range want {
+			if qc == "!"+id {
+				return false
+			}
+		}
+	}
 	return true
 }
 
+// identityColor converts a color identity entry, which mtgjson gives as
+// a letter ("U"), to the short form used in queries.
+func identityColor(id string) string {
+	if c := shortColor(id); c != "" {
+		return c
+	}
+	return strings.ToLower(id)
+}
+
+// textFold normalizes card text for matching, as configured by a Query's
+// CaseSensitive and AccentSensitive options.
+type textFold struct {
+	caseSensitive, accentSensitive bool
+}
+
+// text lowercases s unless matching is case-sensitive.
+func (f textFold) text(s string) string {
+	if f.caseSensitive {
+		return s
+	}
+	return strings.ToLower(s)
+}
+
+// key normalizes a card name for comparison as LookupNormalized does.
+func (f textFold) key(s string) string {
+	if f.accentSensitive {
+		return f.text(s)
+	}
+	return f.text(normalizeCardName(s))
+}
+
+// name normalizes a card name for substring searches, dropping everything
+// but letters and digits.
+func (f textFold) name(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, f.key(s))
+}
+
+// containsText reports whether any of list contains s, normalized by f.
+func containsText(list []string, s string, f textFold) bool {
+	for _, l := range list {
+		if strings.Contains(f.text(l), f.text(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasName reports whether name is the card's name or the name of one of
+// its faces, normalized by f.
+func (c *Card) hasName(name string, f textFold) bool {
+	name = f.key(name)
+	if f.key(c.Name) == name {
+		return true
+	}
+	for _, n := range c.Names {
+		if f.key(n) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// legalInAny reports whether c is legal in at least one of formats.
+func (c *Card) legalInAny(formats []string) bool {
+	for _, f := range formats {
+		if c.hasLegality(f, "Legal") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLegality reports whether c has the given legality in format.
+func (c *Card) hasLegality(format, legality string) bool {
+	return strings.EqualFold(c.Legality(format), legality)
+}
+
+// matchRarity returns the first of rarities that is wanted, normalized.
+func matchRarity(rarities, want []string) (string, bool) {
+	for _, rarity := range rarities {
+		rarity = normalizeRarity(rarity)
+		for _, r := range want {
+			if strings.EqualFold(rarity, r) {
+				return rarity, true
+			}
+		}
+	}
+	return "", false
+}
+
+// normalizeRarity expands abbreviations such as "m" and maps the legacy
+// "Mythic Rare" to "mythic".
+func normalizeRarity(r string) string {
+	switch r = strings.ToLower(r); r {
+	case "m", "mythic rare":
+		return "mythic"
+	case "r":
+		return "rare"
+	case "u":
+		return "uncommon"
+	case "c":
+		return "common"
+	case "s":
+		return "special"
+	}
+	return r
+}
+
+func validRarity(r string) bool {
+	switch r {
+	case "common", "uncommon", "rare", "mythic", "special":
+		return true
+	}
+	return false
+}
+
+// shortColor converts a color as given by mtgjson, either its name
+// ("Blue", in the legacy schema) or its letter ("U"), to its short form.
 func shortColor(long string) string {
 	switch long {
-	case "White":
+	case "White", "W":
 		return "w"
-	case "Blue":
+	case "Blue", "U":
 		return "u"
-	case "Black":
+	case "Black", "B":
 		return "b"
-	case "Red":
+	case "Red", "R":
 		return "r"
-	case "Green":
+	case "Green", "G":
 		return "g"
 	}
 	return ""
 }
 
+// ParseQuery parses a query string, ignoring any malformed terms.
 func ParseQuery(s string) *Query {
+	q, _ := ParseQueryStrict(s)
+	return q
+}
+
+// ParseQueryStrict parses a query string like ParseQuery, but reports an
+// empty query or the first term that could not be parsed, such as one
+// with a bad or missing operand or an unknown prefix. The returned Query
+// is never nil and holds every term that did parse.
+func ParseQueryStrict(s string) (*Query, error) {
 	var q Query
-	for _, s := range strings.Fields(s) {
-		p := func(p string) bool { return strings.HasPrefix(s, p) }
-		switch {
-		case p("o:"):
-			q.Rule = append(q.Rule, strings.ToLower(s[2:]))
-		case p("t:"):
-			q.Type = append(q.Type, strings.ToLower(s[2:]))
-		case p("c:"):
-			for _, c := range strings.ToLower(s[2:]) {
-				if !validColor(c) {
-					continue
-				}
-				q.Color = append(q.Color, string(c))
+	toks := joinExactNames(tokenizeQuoted(s))
+	if len(toks) == 0 {
+		return &q, errors.New("empty query")
+	}
+	groups, firstErr := groupOr(toks)
+	setErr := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, group := range groups {
+		if len(group) == 1 {
+			setErr(q.parseTerm(group[0]))
+			continue
+		}
+		var alts []*Query
+		for _, tok := range group {
+			alt := &Query{}
+			if err := alt.parseTerm(tok); err != nil {
+				// A malformed alternative would otherwise match everything.
+				setErr(err)
+				continue
 			}
-		case p("c!"):
-			for _, c := range strings.ToLower(s[2:]) {
-				if !validColor(c) {
-					continue
-				}
-				q.Color = append(q.Color, "!"+string(c))
+			alts = append(alts, alt)
+		}
+		if len(alts) > 0 {
+			q.Or = append(q.Or, alts)
+		}
+	}
+	return &q, firstErr
+}
+
+// joinExactNames joins each unquoted exact-name token, such as "!lightning",
+// with the unquoted plain name words that follow it.
+func joinExactNames(toks []token) []string {
+	var joined []string
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i].s
+		if len(tok) > 1 && tok[0] == '!' && !toks[i].quoted {
+			for i+1 < len(toks) && !toks[i+1].quoted && isNameWord(toks[i+1].s) {
+				i++
+				tok += " " + toks[i].s
+			}
+		}
+		joined = append(joined, tok)
+	}
+	return joined
+}
+
+// isNameWord reports whether tok is a plain name term, not a term with a
+// prefix or an OR.
+func isNameWord(tok string) bool {
+	if tok == "OR" || tok == "|" {
+		return false
+	}
+	var q Query
+	err := q.parseTerm(tok)
+	return err == nil && reflect.DeepEqual(q, Query{Name: []string{tok}})
+}
+
+// groupOr groups tokens joined by "OR" or "|". Every other token is in a
+// group of its own.
+func groupOr(toks []string) ([][]string, error) {
+	var (
+		groups [][]string
+		err    error
+		join   bool
+	)
+	for _, tok := range toks {
+		if tok == "OR" || tok == "|" {
+			if join || len(groups) == 0 {
+				err = errors.New("dangling OR")
+			}
+			join = true
+			continue
+		}
+		if join && len(groups) > 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], tok)
+		} else {
+			groups = append(groups, []string{tok})
+		}
+		join = false
+	}
+	if join {
+		err = errors.New("dangling OR")
+	}
+	return groups, err
+}
+
+// parseTerm adds a single term to q.
+func (q *Query) parseTerm(s string) error {
+	p := func(p string) bool { return strings.HasPrefix(s, p) }
+	// stat reports whether s is a comparison on the named statistic: the
+	// name must be followed by an operator such as "<" or ":", not a
+	// letter, so that words like "power" and "devoted" are name terms. A
+	// bad operator, as in "cmc~3", is still reported by the stat's parser.
+	stat := func(name string) bool {
+		if !p(name) || len(s) == len(name) {
+			return false
+		}
+		r, _ := utf8.DecodeRuneInString(s[len(name):])
+		return !unicode.IsLetter(r)
+	}
+	for _, pre := range operandPrefixes {
+		if s == pre {
+			return fmt.Errorf("%s: missing operand", s)
+		}
+	}
+	switch {
+	case p("oracle:"):
+		q.Oracle = append(q.Oracle, s[7:])
+	case p("o:"):
+		q.Rule = append(q.Rule, s[2:])
+	case p("t!"):
+		q.NotType = append(q.NotType, s[2:])
+	case p("-t:"):
+		q.NotType = append(q.NotType, s[3:])
+	case p("-o:"):
+		q.NotRule = append(q.NotRule, s[3:])
+	case p("n^"):
+		q.NamePrefix = append(q.NamePrefix, s[2:])
+	case p("n$"):
+		q.NameSuffix = append(q.NameSuffix, s[2:])
+	case p("n!"):
+		q.NotName = append(q.NotName, s[2:])
+	case p("!") && len(s) > 1:
+		q.ExactName = append(q.ExactName, s[1:])
+	case p("-") && len(s) > 1:
+		// Only a leading minus negates; "True-Name" is a plain name term.
+		q.NotName = append(q.NotName, s[1:])
+	case p("ft:"):
+		q.Flavor = append(q.Flavor, s[3:])
+	case p("a:"):
+		q.Artist = append(q.Artist, s[2:])
+	case p("re:"):
+		re, err := regexp.Compile(s[3:])
+		if err != nil {
+			return fmt.Errorf("%s: %v", s, err)
+		}
+		q.Regexp = append(q.Regexp, re)
+	case p("t:"):
+		q.Type = append(q.Type, s[2:])
+	case p("s:"):
+		q.Sets = append(q.Sets, strings.ToLower(s[2:]))
+	case p("st:"):
+		q.SubType = append(q.SubType, strings.ToLower(s[3:]))
+	case p("is:"):
+		pred := strings.ToLower(s[3:])
+		if isPredicates[pred] == nil {
+			return fmt.Errorf("%s: unknown predicate %q", s, s[3:])
+		}
+		q.Is = append(q.Is, pred)
+	case p("kw:"):
+		q.Keyword = append(q.Keyword, strings.ToLower(s[3:]))
+	case p("super:"):
+		q.SuperType = append(q.SuperType, strings.ToLower(s[6:]))
+	case p("mana:"), p("mana="):
+		syms, err := parseManaCost(s[5:])
+		if err != nil {
+			return fmt.Errorf("%s: %v", s, err)
+		}
+		exact := s[4] == '='
+		if len(syms) == 0 && !exact {
+			return fmt.Errorf("%s: missing mana symbols, e.g. mana:{U}", s)
+		}
+		q.Mana = append(q.Mana, ManaConstraint{Symbols: syms, Exact: exact})
+	case p("c:"):
+		colors, err := parseColors(s, s[2:], validColor)
+		for _, c := range colors {
+			q.Color = append(q.Color, string(c))
+		}
+		return err
+	case p("c!"):
+		colors, err := parseColors(s, s[2:], validColor)
+		for _, c := range colors {
+			q.Color = append(q.Color, "!"+string(c))
+		}
+		return err
+	case p("c") && strings.Contains(s, "of:"):
+		i := strings.Index(s, "of:")
+		op, v, err := parseInt(s, s[1:i])
+		if err != nil {
+			return err
+		}
+		colors, err := parseColors(s, s[i+3:], func(c rune) bool {
+			return c != 'm' && c != 'c' && validColor(c)
+		})
+		oc := ColorOverlapConstraint{StatConstraint: StatConstraint{Op: op, Value: v}}
+		for _, c := range colors {
+			if !strings.ContainsRune(oc.Colors, c) {
+				oc.Colors += string(c)
+			}
+		}
+		q.ColorOverlap = append(q.ColorOverlap, oc)
+		return err
+	case p("c="), p("c<="), p("c>="):
+		op, operand := parseComparison(s[1:])
+		colors, err := parseColors(s, operand, func(c rune) bool {
+			return c != 'm' && c != 'c' && validColor(c)
+		})
+		cc := ColorSetConstraint{Op: op}
+		for _, c := range colors {
+			if !strings.ContainsRune(cc.Colors, c) {
+				cc.Colors += string(c)
+			}
+		}
+		q.ColorSet = append(q.ColorSet, cc)
+		return err
+	case p("id:"):
+		colors, err := parseColors(s, s[3:], validIdentity)
+		for _, c := range colors {
+			q.ColorIdentity = append(q.ColorIdentity, string(c))
+		}
+		return err
+	case p("id!"):
+		colors, err := parseColors(s, s[3:], func(c rune) bool {
+			return c != 'c' && validIdentity(c)
+		})
+		for _, c := range colors {
+			q.ColorIdentity = append(q.ColorIdentity, "!"+string(c))
+		}
+		return err
+	case p("r:"):
+		r := normalizeRarity(s[2:])
+		if !validRarity(r) {
+			return fmt.Errorf("%s: unknown rarity %q", s, s[2:])
+		}
+		q.Rarity = append(q.Rarity, r)
+	case p("f:"):
+		q.Format = append(q.Format, strings.ToLower(s[2:]))
+	case p("legal:"):
+		var formats []string
+		for _, f := range strings.Split(strings.ToLower(s[6:]), ",") {
+			if f != "" {
+				formats = append(formats, f)
+			}
+		}
+		if len(formats) == 0 {
+			return fmt.Errorf("%s: no formats", s)
+		}
+		q.Legal = append(q.Legal, formats)
+	case p("banned:"):
+		q.Banned = append(q.Banned, strings.ToLower(s[7:]))
+	case stat("colors"):
+		op, v, err := parseInt(s, s[6:])
+		if err != nil {
+			return err
+		}
+		q.ColorCount = append(q.ColorCount, StatConstraint{Op: op, Value: v})
+	case stat("cmc"):
+		op, operand, err := parseNumber(s, s[3:])
+		if err != nil {
+			return err
+		}
+		v, _ := strconv.ParseFloat(operand, 64)
+		q.CMC = append(q.CMC, CMCConstraint{Op: op, Value: v})
+	case stat("loy"):
+		op, v, err := parseInt(s, s[3:])
+		if err != nil {
+			return err
+		}
+		q.Loyalty = append(q.Loyalty, StatConstraint{Op: op, Value: v})
+	case stat("dev"):
+		i := strings.LastIndex(s, ":")
+		if i < 0 {
+			return fmt.Errorf("%s: missing color, e.g. dev>=3:u", s)
+		}
+		op, v, err := parseInt(s, s[3:i])
+		if err != nil {
+			return err
+		}
+		color := strings.ToLower(s[i+1:])
+		if len(color) != 1 || !validColor(rune(color[0])) || color == "m" || color == "c" {
+			return fmt.Errorf("%s: invalid color %q", s, color)
+		}
+		q.Devotion = append(q.Devotion, DevotionConstraint{
+			Color:          color,
+			StatConstraint: StatConstraint{Op: op, Value: v},
+		})
+	case stat("prints"):
+		op, v, err := parseInt(s, s[6:])
+		if err != nil {
+			return err
+		}
+		q.Prints = append(q.Prints, StatConstraint{Op: op, Value: v})
+	case stat("pow"), stat("tou"):
+		op, v, err := parseInt(s, s[3:])
+		if err != nil {
+			return err
+		}
+		if p("pow") {
+			q.Power = append(q.Power, StatConstraint{Op: op, Value: v})
+		} else {
+			q.Toughness = append(q.Toughness, StatConstraint{Op: op, Value: v})
+		}
+	default:
+		q.Name = append(q.Name, s)
+		// A word before a colon looks like a mistyped prefix. It's kept as
+		// a name term for ParseQuery, which has always done so.
+		if i := strings.IndexByte(s, ':'); i > 0 && i < len(s)-1 && isWord(s[:i]) {
+			return fmt.Errorf("%s: unknown prefix %q", s, s[:i+1])
+		}
+	}
+	return nil
+}
+
+// operandPrefixes are the prefixes whose terms are meaningless without an
+// operand. "mana=" is not one: it matches cards without a mana cost.
+var operandPrefixes = []string{
+	"oracle:", "o:", "-o:", "t:", "t!", "-t:", "st:", "super:", "kw:", "is:",
+	"n^", "n$", "n!", "ft:", "a:", "re:", "s:", "r:", "f:", "legal:", "banned:",
+	"c:", "c!", "c=", "c<=", "c>=", "id:", "id!",
+}
+
+// isWord reports whether s is made only of letters.
+func isWord(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// colorNames maps the names of two- and three-color combinations to
+// their colors.
+var colorNames = map[string]string{
+	// Guilds.
+	"azorius":  "wu",
+	"dimir":    "ub",
+	"rakdos":   "br",
+	"gruul":    "rg",
+	"selesnya": "gw",
+	"orzhov":   "wb",
+	"izzet":    "ur",
+	"golgari":  "bg",
+	"boros":    "rw",
+	"simic":    "gu",
+
+	// Shards.
+	"bant":   "gwu",
+	"esper":  "wub",
+	"grixis": "ubr",
+	"jund":   "brg",
+	"naya":   "rgw",
+
+	// Wedges.
+	"abzan":  "wbg",
+	"jeskai": "urw",
+	"sultai": "bgu",
+	"mardu":  "rwb",
+	"temur":  "gur",
+}
+
+// parseColors returns the colors in s that satisfy valid, along with an
+// error naming the first one that doesn't. s may also be the name of a
+// color combination, such as "izzet". term is used in the error.
+func parseColors(term, s string, valid func(rune) bool) (string, error) {
+	var (
+		colors string
+		err    error
+	)
+	s = strings.ToLower(s)
+	if c, ok := colorNames[s]; ok {
+		s = c
+	}
+	for _, c := range s {
+		if !valid(c) {
+			if err == nil {
+				err = fmt.Errorf("%s: invalid color %q", term, c)
+			}
+			continue
+		}
+		colors += string(c)
+	}
+	return colors, err
+}
+
+// parseNumber splits s (e.g. ">=4") into a comparison operator and a
+// numeric operand. term is used in errors.
+func parseNumber(term, s string) (op, operand string, err error) {
+	op, operand = parseComparison(s)
+	if op == "" {
+		return "", "", fmt.Errorf("%s: unknown operator", term)
+	}
+	if _, err := strconv.ParseFloat(operand, 64); err != nil {
+		return "", "", fmt.Errorf("%s: invalid number %q", term, operand)
+	}
+	return op, operand, nil
+}
+
+// parseInt is like parseNumber, but requires an integer operand.
+func parseInt(term, s string) (op string, v int, err error) {
+	op, operand := parseComparison(s)
+	if op == "" {
+		return "", 0, fmt.Errorf("%s: unknown operator", term)
+	}
+	v, err = strconv.Atoi(operand)
+	if err != nil {
+		return "", 0, fmt.Errorf("%s: invalid integer %q", term, operand)
+	}
+	return op, v, nil
+}
+
+// tokenize splits s into whitespace-separated terms. Double quotes group
+// words into a single term and are removed, so `o:"draw a card"` yields
+// the term "o:draw a card". An unterminated quote runs to the end of s.
+func tokenize(s string) []string {
+	var toks []string
+	for _, tok := range tokenizeQuoted(s) {
+		toks = append(toks, tok.s)
+	}
+	return toks
+}
+
+// A token is a term from tokenizeQuoted.
+type token struct {
+	s      string
+	quoted bool // Whether any of the term was in quotes.
+}
+
+// tokenizeQuoted is like tokenize, but also reports which terms had
+// quotes.
+func tokenizeQuoted(s string) []token {
+	var (
+		toks   []token
+		tok    strings.Builder
+		inTok  bool
+		quoted bool
+		hadQ   bool
+	)
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			hadQ = true
+			inTok = true
+		case unicode.IsSpace(r) && !quoted:
+			if inTok && tok.Len() > 0 {
+				toks = append(toks, token{tok.String(), hadQ})
 			}
+			tok.Reset()
+			inTok, hadQ = false, false
 		default:
-			q.Name = append(q.Name, strings.ToLower(s))
+			tok.WriteRune(r)
+			inTok = true
+		}
+	}
+	if inTok && tok.Len() > 0 {
+		toks = append(toks, token{tok.String(), hadQ})
+	}
+	return toks
+}
+
+// comparisonOps are the supported comparison operators, longest first so
+// that ">=" is not mistaken for ">".
+var comparisonOps = []string{">=", "<=", "!=", "=", "<", ">"}
+
+// parseComparison splits s (e.g. ">=4") into its operator and operand.
+// op is empty if s does not begin with a known operator.
+func parseComparison(s string) (op, operand string) {
+	for _, op := range comparisonOps {
+		if strings.HasPrefix(s, op) {
+			return op, s[len(op):]
 		}
 	}
-	return &q
+	return "", s
 }
 
 func validColor(c rune) bool {
 	switch c {
-	case 'w', 'u', 'b', 'r', 'g', 'm':
+	case 'w', 'u', 'b', 'r', 'g', 'm', 'c':
+		return true
+	}
+	return false
+}
+
+func validIdentity(c rune) bool {
+	switch c {
+	case 'w', 'u', 'b', 'r', 'g', 'c':
 		return true
 	}
 	return false
 }
 
+// Query returns the cards matching the query string q, in no particular
+// order.
 func (c *Cards) Query(q string) ([]*Card, error) {
+	return c.Search(ParseQuery(q)), nil
+}
+
+// Search returns the cards matching q, in no particular order. Unlike
+// Query, it doesn't need to parse q, so a parsed or hand-built Query can
+// be reused. Only the cards in the most selective index covering one of
+// q's terms are matched against it.
+func (c *Cards) Search(q *Query) []*Card {
+	match, _ := c.SearchContext(context.Background(), q)
+	return match
+}
+
+// QueryContext is like Query, but gives up with ctx.Err() if ctx is done
+// before the search finishes.
+func (c *Cards) QueryContext(ctx context.Context, q string) ([]*Card, error) {
+	return c.SearchContext(ctx, ParseQuery(q))
+}
+
+// checkEvery is how many cards SearchContext matches between checks of
+// its context.
+const checkEvery = 256
+
+// SearchContext is like Search, but gives up with ctx.Err() if ctx is
+// done before the search finishes.
+func (c *Cards) SearchContext(ctx context.Context, q *Query) ([]*Card, error) {
 	var match []*Card
-	query := ParseQuery(q)
 	seen := map[string]bool{}
-	for _, card := range c.M {
-		if query.Match(card) && !seen[card.Name] {
-			match = append(match, card)
+	for i, card := range c.candidates(q) {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if q.Match(card) && !seen[card.Name] {
+			if q.ExpandFaces {
+				match = append(match, card.Faces()...)
+			} else {
+				match = append(match, card)
+			}
 			seen[card.Name] = true
 		}
 	}
 	return match, nil
 }
 
+// SortOrder is an ordering of query results.
+type SortOrder int
+
+const (
+	SortByName   SortOrder = iota // Alphabetical.
+	SortByCMC                     // Converted mana cost, then name.
+	SortByRarity                  // Common to mythic, then name.
+)
+
+// QuerySorted returns the cards matching q in the given order.
+func (c *Cards) QuerySorted(q string, order SortOrder) ([]*Card, error) {
+	match, err := c.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	sortCards(match, order)
+	return match, nil
+}
+
+// QueryJSON returns the cards matching q as a JSON array sorted by name.
+// Cards are encoded by their json struct tags, with mtgjson's field
+// names. Power, Toughness and Loyalty are strings, and a card's empty
+// lists are null. If nothing matches, the array is empty.
+func (c *Cards) QueryJSON(q string) ([]byte, error) {
+	match, err := c.QuerySorted(q, SortByName)
+	if err != nil {
+		return nil, err
+	}
+	if match == nil {
+		match = []*Card{}
+	}
+	return json.Marshal(match)
+}
+
+func sortCards(cards []*Card, order SortOrder) {
+	sort.Slice(cards, func(i, j int) bool {
+		a, b := cards[i], cards[j]
+		switch order {
+		case SortByCMC:
+			if a.CMC != b.CMC {
+				return a.CMC < b.CMC
+			}
+		case SortByRarity:
+			if ra, rb := cardRarityRank(a), cardRarityRank(b); ra != rb {
+				return ra < rb
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+// cardRarityRank ranks a card by its most common rarity, so a card
+// printed at both uncommon and rare sorts with the uncommons.
+func cardRarityRank(c *Card) int {
+	rank := rarityRank("")
+	for _, r := range c.rarities() {
+		if rr := rarityRank(r); rr < rank {
+			rank = rr
+		}
+	}
+	return rank
+}
+
+func rarityRank(r string) int {
+	switch normalizeRarity(r) {
+	case "common":
+		return 0
+	case "uncommon":
+		return 1
+	case "rare":
+		return 2
+	case "mythic":
+		return 3
+	case "special":
+		return 4
+	}
+	return 5
+}
+
+// QueryPage returns the cards matching q, sorted by name, starting at
+// offset and containing at most limit cards. A negative limit returns
+// all remaining cards. total is the number of cards matching q.
+func (c *Cards) QueryPage(q string, offset, limit int) (page []*Card, total int, err error) {
+	page, total = c.SearchPage(ParseQuery(q), offset, limit)
+	return page, total, nil
+}
+
+// SearchPage is like QueryPage, but for a parsed or hand-built Query.
+func (c *Cards) SearchPage(q *Query, offset, limit int) (page []*Card, total int) {
+	match := c.Search(q)
+	sortCards(match, SortByName)
+	total = len(match)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if limit < 0 || end > total {
+		end = total
+	}
+	return match[offset:end], total
+}
+
 const debug = false
 
 func debugf(format string, args ...interface{}) {
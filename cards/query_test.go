@@ -1,7 +1,13 @@
 package cards
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -23,6 +29,108 @@ func TestParseQuery(t *testing.T) {
 				Type:  []string{"pixie"},
 			},
 		},
+		{
+			`o:"draw a card" "Serra Angel" t:"legendary creature"`,
+			Query{
+				Name: []string{"Serra Angel"},
+				Rule: []string{"draw a card"},
+				Type: []string{"legendary creature"},
+			},
+		},
+		{
+			"r:m r:Rare r:c r:uncommon",
+			Query{Rarity: []string{"mythic", "rare", "common", "uncommon"}},
+		},
+		{
+			"f:Commander banned:standard f:modern",
+			Query{
+				Format: []string{"commander", "modern"},
+				Banned: []string{"standard"},
+			},
+		},
+		{
+			"id:BGx id!rc",
+			Query{ColorIdentity: []string{"b", "g", "!r"}},
+		},
+		{
+			"c=UR c<=wm c>=wwu",
+			Query{ColorSet: []ColorSetConstraint{{"=", "ur"}, {"<=", "w"}, {">=", "wu"}}},
+		},
+		{
+			"pow>=5 tou<=2 pow!=x tou=-1",
+			Query{
+				Power:     []StatConstraint{{">=", 5}},
+				Toughness: []StatConstraint{{"<=", 2}, {"=", -1}},
+			},
+		},
+		{
+			"c:r OR c:g t:creature o:a | o:b",
+			Query{
+				Type: []string{"creature"},
+				Or: [][]*Query{
+					{{Color: []string{"r"}}, {Color: []string{"g"}}},
+					{{Rule: []string{"a"}}, {Rule: []string{"b"}}},
+				},
+			},
+		},
+		{
+			"OR c:x OR c:r OR",
+			Query{Or: [][]*Query{{{Color: []string{"r"}}}}},
+		},
+		{
+			"st:Goblin super:legendary",
+			Query{SubType: []string{"goblin"}, SuperType: []string{"legendary"}},
+		},
+		{
+			"loy>=4 loy=x",
+			Query{Loyalty: []StatConstraint{{">=", 4}}},
+		},
+		{
+			"dev>=3:U dev=1:x dev>=2",
+			Query{Devotion: []DevotionConstraint{{"u", StatConstraint{">=", 3}}}},
+		},
+		{
+			`ft:"The Multiverse" a:Guay`,
+			Query{Flavor: []string{"The Multiverse"}, Artist: []string{"Guay"}},
+		},
+		{
+			"s:MH2 s:dom",
+			Query{Sets: []string{"mh2", "dom"}},
+		},
+		{
+			"t!Artifact -t:land -o:defender n!island -snow true-name -",
+			Query{
+				Name:    []string{"true-name", "-"},
+				NotName: []string{"island", "snow"},
+				NotRule: []string{"defender"},
+				NotType: []string{"Artifact", "land"},
+			},
+		},
+		{
+			"c:c colors>=2 colors=3 colors>x",
+			Query{
+				Color:      []string{"c"},
+				ColorCount: []StatConstraint{{">=", 2}, {"=", 3}},
+			},
+		},
+		{
+			"cmc>=4 cmc<3 cmc=2.5 cmc!=0 cmc>=abc cmc~1",
+			Query{
+				CMC: []CMCConstraint{
+					{">=", 4},
+					{"<", 3},
+					{"=", 2.5},
+					{"!=", 0},
+				},
+			},
+		},
+		// Stat names followed by letters are ordinary name words.
+		{"power sink", Query{Name: []string{"power", "sink"}}},
+		{"loyal retainers", Query{Name: []string{"loyal", "retainers"}}},
+		{"devoted druid", Query{Name: []string{"devoted", "druid"}}},
+		{"toughness", Query{Name: []string{"toughness"}}},
+		{"powerstone shard", Query{Name: []string{"powerstone", "shard"}}},
+		{"colorsmith cmcx printsy", Query{Name: []string{"colorsmith", "cmcx", "printsy"}}},
 	} {
 		got, want := ParseQuery(c.s), &c.q
 		if !reflect.DeepEqual(got, want) {
@@ -31,6 +139,77 @@ func TestParseQuery(t *testing.T) {
 	}
 }
 
+func TestTokenize(t *testing.T) {
+	for _, c := range []struct {
+		s    string
+		want []string
+	}{
+		{"blah  boo\tbong", []string{"blah", "boo", "bong"}},
+		{`o:"draw a card" foo`, []string{"o:draw a card", "foo"}},
+		{`"Serra Angel"`, []string{"Serra Angel"}},
+		{`t:"legendary creature" c:g`, []string{"t:legendary creature", "c:g"}},
+		{`foo o:"draw a`, []string{"foo", "o:draw a"}},
+		{`"" bar`, []string{"bar"}},
+		{"", nil},
+	} {
+		if got := tokenize(c.s); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", c.s, got, c.want)
+		}
+	}
+}
+
+func TestParseQueryStrict(t *testing.T) {
+	for _, c := range []struct {
+		s   string
+		err string
+	}{
+		{"", "empty query"},
+		{"   ", "empty query"},
+		{"cmc>=foo", `cmc>=foo: invalid number "foo"`},
+		{"cmc~3", "cmc~3: unknown operator"},
+		{"pow>=1.5", `pow>=1.5: invalid integer "1.5"`},
+		{"c:rx", `c:rx: invalid color 'x'`},
+		{"r:legendary", `r:legendary: unknown rarity "legendary"`},
+		{"shock c:r cmc<=1", ""},
+		{"dev>=3", "dev>=3: missing color, e.g. dev>=3:u"},
+		{"c:r OR", "dangling OR"},
+		{"c:r OR OR c:g", "dangling OR"},
+		{"power sink", ""},
+		{"toughness", ""},
+		{"pow:3", "pow:3: unknown operator"},
+		{"f:", "f:: missing operand"},
+		{"t:", "t:: missing operand"},
+		{"c:", "c:: missing operand"},
+		{"n^", "n^: missing operand"},
+		{"bolt c:r id:", "id:: missing operand"},
+		{"mana=", ""},
+		{"foo:bar", `foo:bar: unknown prefix "foo:"`},
+		{"T:creature", `T:creature: unknown prefix "T:"`},
+		{"circle of protection: red", ""},
+		{`"Circle of Protection: Red"`, ""},
+	} {
+		q, err := ParseQueryStrict(c.s)
+		if q == nil {
+			t.Errorf("ParseQueryStrict(%q) returned a nil query", c.s)
+		}
+		var got string
+		if err != nil {
+			got = err.Error()
+		}
+		if got != c.err {
+			t.Errorf("ParseQueryStrict(%q): got error %q, want %q", c.s, got, c.err)
+		}
+	}
+
+	// The lenient parser keeps the terms that did parse, and still reads
+	// unknown prefixes as names.
+	q := ParseQuery("c:rx cmc>=foo bolt t: foo:bar")
+	want := &Query{Name: []string{"bolt", "foo:bar"}, Color: []string{"r"}}
+	if !reflect.DeepEqual(q, want) {
+		t.Errorf("got %+v, want %+v", q, want)
+	}
+}
+
 var testCards = []*Card{
 	{
 		Colors: []string{"Blue"},
@@ -65,3 +244,1031 @@ func TestQueryMatch(t *testing.T) {
 		t.Errorf("no name match; want match")
 	}
 }
+
+func TestQueryCMC(t *testing.T) {
+	for _, c := range []struct {
+		q     string
+		cmc   float64
+		match bool
+	}{
+		{"cmc>=4", 4, true},
+		{"cmc>=4", 3, false},
+		{"cmc<=2", 2, true},
+		{"cmc<=2", 3, false},
+		{"cmc=2.5", 2.5, true},
+		{"cmc=2", 2.5, false},
+		{"cmc<3", 2, true},
+		{"cmc<3", 3, false},
+		{"cmc>0", 1, true},
+		{"cmc>0", 0, false},
+		{"cmc!=0", 1, true},
+		{"cmc!=0", 0, false},
+		{"cmc>=abc", 0, true},
+	} {
+		if got := ParseQuery(c.q).Match(&Card{CMC: c.cmc}); got != c.match {
+			t.Errorf("%q on cmc %v: got match %v, want %v", c.q, c.cmc, got, c.match)
+		}
+	}
+}
+
+func TestQueryRarity(t *testing.T) {
+	mythic := &Card{Name: "Jace, the Mind Sculptor", Rarity: "Mythic Rare"}
+	rare := &Card{Name: "Force of Will", Rarity: "Rare"}
+	common := &Card{Name: "Shock", Rarity: "Common"}
+
+	q := ParseQuery("r:mythic")
+	if !q.Match(mythic) {
+		t.Errorf("r:mythic: no match for mythic; want match")
+	}
+	if q.Match(rare) {
+		t.Errorf("r:mythic: match for rare; want no match")
+	}
+
+	q = ParseQuery("r:m r:c")
+	if !q.Match(mythic) || !q.Match(common) {
+		t.Errorf("r:m r:c: want match for mythic and common")
+	}
+	if q.Match(rare) {
+		t.Errorf("r:m r:c: match for rare; want no match")
+	}
+}
+
+func TestQueryFormat(t *testing.T) {
+	lotus := &Card{
+		Name: "Black Lotus",
+		Legalities: []FormatLegality{
+			{"Commander", "Banned"},
+			{"Legacy", "Banned"},
+			{"Modern", "Banned"},
+			{"Vintage", "Restricted"},
+		},
+	}
+	bolt := &Card{
+		Name: "Lightning Bolt",
+		Legalities: []FormatLegality{
+			{"Commander", "Legal"},
+			{"Legacy", "Legal"},
+			{"Modern", "Legal"},
+			{"Vintage", "Legal"},
+		},
+	}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"f:modern", bolt, true},
+		{"f:modern", lotus, false},
+		{"f:vintage", lotus, false},
+		{"f:standard", bolt, false},
+		{"banned:modern", lotus, true},
+		{"banned:modern", bolt, false},
+		{"banned:vintage", lotus, false},
+		{"f:commander f:legacy", bolt, true},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryLegalAny(t *testing.T) {
+	modernOnly := &Card{
+		Name: "Thoughtseize",
+		Legalities: []FormatLegality{
+			{"Modern", "Legal"},
+			{"Standard", "Not Legal"},
+			{"Pioneer", "Banned"},
+		},
+	}
+
+	for _, c := range []struct {
+		q     string
+		match bool
+	}{
+		{"legal:standard,modern", true},
+		{"legal:Standard,Modern,Pioneer", true},
+		{"legal:modern", true},
+		{"legal:standard,pioneer", false},
+		{"f:standard f:modern", false},
+		{"legal:standard,modern legal:pioneer", false},
+	} {
+		if got := ParseQuery(c.q).Match(modernOnly); got != c.match {
+			t.Errorf("%q: got match %v, want %v", c.q, got, c.match)
+		}
+	}
+	if _, err := ParseQueryStrict("legal:,"); err == nil {
+		t.Error("got no error for legal:,")
+	}
+}
+
+func TestQueryColorIdentity(t *testing.T) {
+	solRing := &Card{Name: "Sol Ring"}
+	abrupt := &Card{Name: "Abrupt Decay", ColorIdentity: []string{"B", "G"}}
+	elf := &Card{Name: "Llanowar Elves", ColorIdentity: []string{"G"}}
+	jund := &Card{Name: "Bloodbraid Elf", ColorIdentity: []string{"B", "R", "G"}}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"id:c", solRing, true},
+		{"id:c", elf, false},
+		{"id:bg", solRing, true},
+		{"id:bg", abrupt, true},
+		{"id:bg", elf, true},
+		{"id:bg", jund, false},
+		{"id!r", jund, false},
+		{"id!r", abrupt, true},
+		{"id!g", solRing, true},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryColorSet(t *testing.T) {
+	izzet := &Card{Name: "Izzet Charm", Colors: []string{"Blue", "Red"}}
+	fiveColor := &Card{Name: "Progenitus", Colors: []string{"White", "Blue", "Black", "Red", "Green"}}
+	monoWhite := &Card{Name: "Swords to Plowshares", Colors: []string{"White"}}
+	colorless := &Card{Name: "Sol Ring"}
+	azorius := &Card{Name: "Azorius Charm", Colors: []string{"White", "Blue"}}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"c=ur", izzet, true},
+		{"c=ur", fiveColor, false},
+		{"c:ur", fiveColor, true},
+		{"c<=w", monoWhite, true},
+		{"c<=w", colorless, true},
+		{"c<=w", azorius, false},
+		{"c>=wu", azorius, true},
+		{"c>=wu", fiveColor, true},
+		{"c>=wu", monoWhite, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryColorOverlap(t *testing.T) {
+	esperPair := &Card{Name: "Dovin's Veto", Colors: []string{"White", "Blue"}}
+	monoWhite := &Card{Name: "Swords to Plowshares", Colors: []string{"White"}}
+	izzet := &Card{Name: "Izzet Charm", Colors: []string{"Blue", "Red"}}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"c>=2of:wub", esperPair, true},
+		{"c>=2of:wub", monoWhite, false},
+		{"c>=2of:wub", izzet, false},
+		{"c>=2of:esper", esperPair, true},
+		{"c=1of:wub", izzet, true},
+		{"c<2of:wub", monoWhite, true},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+
+	for _, q := range []string{"c>=xof:wub", "c>=2of:wxb", "c2of:wub"} {
+		if _, err := ParseQueryStrict(q); err == nil {
+			t.Errorf("%q: got no error", q)
+		}
+	}
+}
+
+func TestQueryPowerToughness(t *testing.T) {
+	craw := &Card{Name: "Craw Wurm", Power: "6", Toughness: "4"}
+	baloth := &Card{Name: "Krosan Tusker", Power: "6", Toughness: "5"}
+	bear := &Card{Name: "Grizzly Bears", Power: "2", Toughness: "2"}
+	tarmo := &Card{Name: "Tarmogoyf", Power: "*", Toughness: "1+*"}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"pow>=5 tou>=5", baloth, true},
+		{"pow>=5 tou>=5", craw, false},
+		{"pow>=5 tou>=5", bear, false},
+		{"pow>=1", tarmo, false},
+		{"pow<1", tarmo, false},
+		{"pow=2 tou=2", bear, true},
+		{"pow!=2", bear, false},
+		{"pow>2", craw, true},
+		{"tou<5", craw, true},
+		{"tou<=4", baloth, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryOr(t *testing.T) {
+	goblin := &Card{Name: "Goblin Guide", Colors: []string{"Red"}, Type: "Creature — Goblin Scout"}
+	elf := &Card{Name: "Llanowar Elves", Colors: []string{"Green"}, Type: "Creature — Elf Druid"}
+	merfolk := &Card{Name: "Lord of Atlantis", Colors: []string{"Blue"}, Type: "Creature — Merfolk"}
+	bolt := &Card{Name: "Lightning Bolt", Colors: []string{"Red"}, Type: "Instant"}
+
+	q := ParseQuery("c:r OR c:g t:creature")
+	for _, c := range []struct {
+		card  *Card
+		match bool
+	}{
+		{goblin, true},
+		{elf, true},
+		{merfolk, false},
+		{bolt, false},
+	} {
+		if got := q.Match(c.card); got != c.match {
+			t.Errorf("%s: got match %v, want %v", c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQuerySubSuperType(t *testing.T) {
+	krenko := &Card{
+		Name:       "Krenko, Mob Boss",
+		Type:       "Legendary Creature — Goblin Warrior",
+		SuperTypes: []string{"Legendary"},
+		Types:      []string{"Creature"},
+		SubTypes:   []string{"Goblin", "Warrior"},
+	}
+	// A crafted card whose Types, but not SubTypes, mention Goblin.
+	odd := &Card{
+		Name:  "Goblin Test Card",
+		Type:  "Goblin",
+		Types: []string{"Goblin"},
+	}
+	pacifism := &Card{
+		Name:     "Pacifism",
+		Type:     "Enchantment — Aura",
+		Types:    []string{"Enchantment"},
+		SubTypes: []string{"Aura"},
+	}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"st:goblin", krenko, true},
+		{"st:GOBLIN st:warrior", krenko, true},
+		{"st:goblin", odd, false},
+		{"st:aura", pacifism, true},
+		{"st:aura", krenko, false},
+		{"super:legendary", krenko, true},
+		{"super:legendary", pacifism, false},
+		{"super:basic", krenko, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryLoyalty(t *testing.T) {
+	jace := &Card{Name: "Jace, the Mind Sculptor", Type: "Legendary Planeswalker — Jace", Loyalty: "3"}
+	ugin := &Card{Name: "Ugin, the Spirit Dragon", Type: "Legendary Planeswalker — Ugin", Loyalty: "7"}
+	nissa := &Card{Name: "Nissa, Steward of Elements", Type: "Legendary Planeswalker — Nissa", Loyalty: "X"}
+	bear := &Card{Name: "Grizzly Bears", Type: "Creature — Bear"}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"t:planeswalker loy>=4", ugin, true},
+		{"t:planeswalker loy>=4", jace, false},
+		{"t:planeswalker loy>=4", nissa, false},
+		{"t:planeswalker loy<=4", nissa, false},
+		{"t:planeswalker", nissa, true},
+		{"loy=3", jace, true},
+		{"loy<=7", bear, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryRegexp(t *testing.T) {
+	divination := &Card{Name: "Divination", Text: "Draw two cards."}
+	opt := &Card{Name: "Opt", Text: "Scry 1.\nDraw a card."}
+	shock := &Card{Name: "Shock", Text: "Shock deals 2 damage to any target."}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"re:^Draw", divination, true},
+		{"re:^Draw", opt, false},
+		{`re:"deals \d+ damage"`, shock, true},
+		{`re:"deals \d+ damage"`, divination, false},
+	} {
+		q, err := ParseQueryStrict(c.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := q.Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+
+	if _, err := ParseQueryStrict("re:(unclosed"); err == nil {
+		t.Errorf("re:(unclosed: got no error, want compile error")
+	}
+}
+
+func testCorpus(cards ...*Card) *Cards {
+	m := make(map[string]*Card)
+	for _, c := range cards {
+		m[c.Name] = c
+	}
+	return NewCards(m)
+}
+
+func TestQueryPage(t *testing.T) {
+	var all []*Card
+	for i := 0; i < 25; i++ {
+		all = append(all, &Card{Name: fmt.Sprintf("Goblin %02d", i), Type: "Creature — Goblin"})
+	}
+	corpus := testCorpus(append(all, &Card{Name: "Island", Type: "Basic Land — Island"})...)
+
+	want, err := corpus.Query("t:goblin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(want, func(i, j int) bool { return want[i].Name < want[j].Name })
+
+	var got []*Card
+	seen := map[string]bool{}
+	for offset := 0; offset < 30; offset += 10 {
+		page, total, err := corpus.QueryPage("t:goblin", offset, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 25 {
+			t.Errorf("offset %d: got total %d, want 25", offset, total)
+		}
+		for _, c := range page {
+			if seen[c.Name] {
+				t.Errorf("offset %d: %q already returned by an earlier page", offset, c.Name)
+			}
+			seen[c.Name] = true
+		}
+		got = append(got, page...)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pages don't reproduce the sorted result set")
+	}
+
+	if page, _, _ := corpus.QueryPage("t:goblin", 100, 10); len(page) != 0 {
+		t.Errorf("got %d cards past the end, want 0", len(page))
+	}
+	if page, _, _ := corpus.QueryPage("t:goblin", 20, -1); len(page) != 5 {
+		t.Errorf("got %d cards with no limit, want 5", len(page))
+	}
+}
+
+func TestQuerySorted(t *testing.T) {
+	corpus := testCorpus(
+		&Card{Name: "Ornithopter", CMC: 0, Rarity: "Uncommon"},
+		&Card{Name: "Wurmcoil Engine", CMC: 6, Rarity: "Mythic Rare"},
+		&Card{Name: "Sol Ring", CMC: 1, Rarity: "Uncommon"},
+		&Card{Name: "Memnite", CMC: 0, Rarity: "Uncommon"},
+		&Card{Name: "Mox Opal", CMC: 0, Rarity: "Mythic Rare"},
+		&Card{Name: "Shock", CMC: 1, Rarity: "Common"},
+	)
+	names := func(cards []*Card) []string {
+		var s []string
+		for _, c := range cards {
+			s = append(s, c.Name)
+		}
+		return s
+	}
+
+	first, err := corpus.QuerySorted("", SortByName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		again, _ := corpus.QuerySorted("", SortByName)
+		if !reflect.DeepEqual(names(again), names(first)) {
+			t.Fatalf("got %q, then %q; want identical ordering", names(first), names(again))
+		}
+	}
+
+	for _, c := range []struct {
+		order SortOrder
+		want  []string
+	}{
+		{SortByName, []string{"Memnite", "Mox Opal", "Ornithopter", "Shock", "Sol Ring", "Wurmcoil Engine"}},
+		{SortByCMC, []string{"Memnite", "Mox Opal", "Ornithopter", "Shock", "Sol Ring", "Wurmcoil Engine"}},
+		{SortByRarity, []string{"Shock", "Memnite", "Ornithopter", "Sol Ring", "Mox Opal", "Wurmcoil Engine"}},
+	} {
+		got, err := corpus.QuerySorted("", c.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(names(got), c.want) {
+			t.Errorf("order %d: got %q, want %q", c.order, names(got), c.want)
+		}
+	}
+
+	got, _ := corpus.QuerySorted("cmc=0 OR cmc=6", SortByCMC)
+	if got[0].CMC != 0 || got[len(got)-1].Name != "Wurmcoil Engine" {
+		t.Errorf("SortByCMC: got %q, want 0-cost cards before the 6-cost one", names(got))
+	}
+}
+
+func TestQueryDevotion(t *testing.T) {
+	thassa := &Card{Name: "Thassa's Oracle", ManaCost: "{U}{U}"}
+	master := &Card{Name: "Master of Waves", ManaCost: "{3}{U}"}
+	cryptic := &Card{Name: "Cryptic Command", ManaCost: "{1}{U}{U}{U}"}
+	hybrid := &Card{Name: "Thought Reflection Test", ManaCost: "{U/B}{U/B}{U}"}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"dev>=3:u", cryptic, true},
+		{"dev>=3:u", master, false},
+		{"dev>=3:u", thassa, false},
+		{"dev>=3:u", hybrid, true},
+		{"dev=2:b", hybrid, true},
+		{"dev=0:r", master, true},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryFlavorArtist(t *testing.T) {
+	elder := &Card{
+		Name:   "Elder Deep-Fiend",
+		Flavor: "Its hunger spans the multiverse.",
+		Artist: "Jason Felix",
+	}
+	rules := &Card{
+		Name: "Rules Test Card",
+		Text: "Search the multiverse for a card.",
+	}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{`ft:"the multiverse"`, elder, true},
+		{`ft:"the multiverse"`, rules, false},
+		{`o:"the multiverse"`, elder, false},
+		{"a:felix", elder, true},
+		{`a:"jason felix"`, elder, true},
+		{"a:guay", elder, false},
+		{"a:felix", rules, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQuerySets(t *testing.T) {
+	counterspell := &Card{
+		Name:      "Counterspell",
+		Colors:    []string{"Blue"},
+		Type:      "Instant",
+		Printings: []string{"LEA", "MH2", "TMP"},
+	}
+	opt := &Card{
+		Name:      "Opt",
+		Colors:    []string{"Blue"},
+		Type:      "Instant",
+		Printings: []string{"INV", "DOM"},
+	}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"s:mh2", counterspell, true},
+		{"s:mh2", opt, false},
+		{"c:u t:instant s:MH2", counterspell, true},
+		{"s:dom s:inv", opt, true},
+		{"s:dom s:mh2", opt, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryMana(t *testing.T) {
+	counterspell := &Card{Name: "Counterspell", ManaCost: "{U}{U}"}
+	opt := &Card{Name: "Opt", ManaCost: "{U}"}
+	divination := &Card{Name: "Divination", ManaCost: "{2}{U}"}
+	dack := &Card{Name: "Dack's Duplicate", ManaCost: "{2}{U/R}{U/R}"}
+	bear := &Card{Name: "Grizzly Bears", ManaCost: "{1}{G}"}
+	land := &Card{Name: "Island"}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"mana={2}{U}", divination, true},
+		{"mana={U}{2}", divination, true},
+		{"mana={2}{U}", opt, false},
+		{"mana={2}{U}", dack, false},
+		{"mana={u}{u}", counterspell, true},
+		{"mana:{U}", counterspell, true},
+		{"mana:{U}", divination, true},
+		{"mana:{U}", bear, false},
+		{"mana:{U}{U}", opt, false},
+		{"mana:{U}{U}", counterspell, true},
+		{"mana:{U/R}", dack, true},
+		{"mana:{U/R}{U/R}{2}", dack, true},
+		{"mana:{U/R}", divination, false},
+		{"mana:{U}", dack, false},
+		{"mana=", land, true},
+		{"mana=", opt, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+	for _, s := range []string{"mana:", "mana:{Q}", "mana=U"} {
+		if _, err := ParseQueryStrict(s); err == nil {
+			t.Errorf("got no error for %q", s)
+		}
+	}
+}
+
+func TestQueryNameAnchors(t *testing.T) {
+	bolt := &Card{Name: "Lightning Bolt"}
+	sunlight := &Card{Name: "Sunlight"}
+	jace := &Card{Name: "Jace, the Mind Sculptor"}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"n^light", bolt, true},
+		{"n^light", sunlight, false},
+		{"n$light", sunlight, true},
+		{"n$light", bolt, false},
+		{"n^Lightning n$bolt", bolt, true},
+		{"n^bolt", bolt, false},
+		{`n^"jace, the"`, jace, true},
+		{"n^jacethe", jace, true},
+		{"n$sculptor", jace, true},
+		{"n$mind", jace, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryKeywords(t *testing.T) {
+	angel := &Card{Name: "Serra Angel", Text: "Flying, vigilance", Keywords: []string{"Flying", "Vigilance"}}
+	spider := &Card{
+		Name:     "Giant Spider",
+		Text:     "Reach (This creature can block creatures with flying.)",
+		Keywords: []string{"Reach"},
+	}
+	owl := &Card{Name: "Owlbear", Text: "Trample", Flavor: "Flying is for other owls.", Keywords: []string{"Trample"}}
+	knight := &Card{Name: "White Knight", Text: "First strike\nProtection from black", Keywords: []string{"First strike", "Protection"}}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"kw:flying", angel, true},
+		{"kw:Flying kw:vigilance", angel, true},
+		{"kw:flying", spider, false},
+		{"o:flying", spider, true},
+		{"kw:flying", owl, false},
+		{"kw:fly", angel, false},
+		{`kw:"first strike" kw:protection`, knight, true},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryOracle(t *testing.T) {
+	spike := &Card{
+		Name: "Rhox Faithmender",
+		Text: "Lifelink\nIf you would gain life, you gain twice that much life instead.",
+	}
+	// Only mentions trample in reminder text.
+	rampager := &Card{
+		Name: "Hooded Brawler",
+		Text: "Exert (An exerted creature won't untap during your next untap step. It gains trample until end of turn.)",
+	}
+	craw := &Card{Name: "Craw Wurm"}
+	colossus := &Card{Name: "Colossal Dreadmaw", Text: "Trample (This creature can deal excess combat damage to the player or planeswalker it's attacking.)"}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"o:trample", rampager, true},
+		{"oracle:trample", rampager, false},
+		{"oracle:trample", colossus, true},
+		{"oracle:excess", colossus, false},
+		{"oracle:exert", rampager, true},
+		{"oracle:lifelink", spike, true},
+		{"oracle:trample", craw, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+
+	if got, want := colossus.TextWithoutReminders(), "Trample"; got != want {
+		t.Errorf("got text without reminders %q, want %q", got, want)
+	}
+}
+
+func TestQueryIs(t *testing.T) {
+	bears := &Card{Name: "Grizzly Bears", Type: "Creature — Bear", Types: []string{"Creature"}}
+	fireIce := &Card{Name: "Fire // Ice", Names: []string{"Fire", "Ice"}, Layout: "split", Type: "Instant // Instant", Types: []string{"Instant"}, Text: "Fire deals 2 damage divided as you choose among one or two targets.\n//\nTap target permanent.\nDraw a card."}
+	delver := &Card{Name: "Delver of Secrets // Insectile Aberration", Names: []string{"Delver of Secrets", "Insectile Aberration"}, Layout: "transform", Type: "Creature — Human Wizard // Creature — Human Insect", Types: []string{"Creature"}, Text: "At the beginning of your upkeep, look at the top card of your library."}
+	krenko := &Card{Name: "Krenko, Mob Boss", Type: "Legendary Creature — Goblin Warrior", SuperTypes: []string{"Legendary"}, Types: []string{"Creature"}, Text: "{T}: Create X 1/1 red Goblin creature tokens, where X is the number of Goblins you control."}
+	teferi := &Card{Name: "Teferi, Temporal Archmage", Type: "Legendary Planeswalker — Teferi", SuperTypes: []string{"Legendary"}, Types: []string{"Planeswalker"}, Text: "−1: Untap up to four target permanents.\nTeferi, Temporal Archmage can be your commander."}
+	jace := &Card{Name: "Jace Beleren", Type: "Legendary Planeswalker — Jace", SuperTypes: []string{"Legendary"}, Types: []string{"Planeswalker"}, Text: "+2: Each player draws a card."}
+	legacy := &Card{Name: "Legacy Split // Card", Names: []string{"Legacy Split", "Card"}, Type: "Sorcery // Sorcery", Text: "Draw a card."}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"is:vanilla", bears, true},
+		{"is:vanilla", krenko, false},
+		{"is:split", fireIce, true},
+		{"is:split", delver, false},
+		{"is:split", bears, false},
+		{"is:split", legacy, true},
+		{"is:dfc", delver, true},
+		{"is:dfc", fireIce, false},
+		{"is:commander", krenko, true},
+		{"is:commander", teferi, true},
+		{"is:commander", jace, false},
+		{"is:commander", bears, false},
+		{"is:permanent", bears, true},
+		{"is:permanent", fireIce, false},
+		{"is:spell", fireIce, true},
+		{"is:spell", legacy, true},
+		{"is:permanent", legacy, false},
+		{"is:Spell is:split", fireIce, true},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+	if _, err := ParseQueryStrict("is:fancy"); err == nil {
+		t.Error("got no error for is:fancy")
+	}
+
+	// A Query built by hand can hold a predicate the parser would reject.
+	fancy := &Query{Is: []string{"fancy"}}
+	if fancy.Match(bears) {
+		t.Error("is:fancy matched Grizzly Bears, want no match")
+	}
+	corpus := NewCards(map[string]*Card{bears.Name: bears, krenko.Name: krenko})
+	if got := corpus.Search(fancy); len(got) != 0 {
+		t.Errorf("searching is:fancy: got %q, want nothing", sortedNames(got))
+	}
+}
+
+func TestQueryPrints(t *testing.T) {
+	once := &Card{Name: "Jedit Ojanen of Efrava", Printings: []string{"TSP"}}
+	staple := &Card{Name: "Sol Ring", Printings: []string{"LEA", "LEB", "2ED", "CED", "3ED", "C13", "C14", "C15", "C16", "C17", "CMR", "EMA"}}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"prints=1", once, true},
+		{"prints=1", staple, false},
+		{"prints>=10", staple, true},
+		{"prints>=10", once, false},
+		{"prints>1", once, false},
+		{"prints<2", once, true},
+		{"prints!=1", staple, true},
+		{"prints>5 prints<=12", staple, true},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+	if _, err := ParseQueryStrict("prints>x"); err == nil {
+		t.Error("got no error for prints>x")
+	}
+}
+
+func TestQueryNegation(t *testing.T) {
+	bear := &Card{Name: "Grizzly Bears", Type: "Creature — Bear"}
+	golem := &Card{Name: "Stone Golem", Type: "Artifact Creature — Golem"}
+	angel := &Card{Name: "Serra Angel", Type: "Creature — Angel", Text: "Flying\nVigilance"}
+	wall := &Card{Name: "Wall of Air", Type: "Creature — Wall", Text: "Defender\nFlying"}
+	island := &Card{Name: "Island", Type: "Basic Land — Island"}
+	snowIsland := &Card{Name: "Snow-Covered Island", Type: "Basic Snow Land — Island"}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"t:creature t!artifact", bear, true},
+		{"t:creature t!artifact", golem, false},
+		{"o:flying -o:defender", angel, true},
+		{"o:flying -o:defender", wall, false},
+		{"island -snow", island, true},
+		{"island -snow", snowIsland, false},
+		{"n!island", bear, true},
+		{"n!island", island, false},
+		{"snow-covered", snowIsland, true},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryColorless(t *testing.T) {
+	solRing := &Card{Name: "Sol Ring", Type: "Artifact"}
+	temple := &Card{Name: "Temple of Mystery", Type: "Land", ColorIdentity: []string{"G", "U"}}
+	bolt := &Card{Name: "Lightning Bolt", Colors: []string{"Red"}}
+	charm := &Card{Name: "Esper Charm", Colors: []string{"White", "Blue", "Black"}}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"c:c", solRing, true},
+		{"c:c", temple, true},
+		{"c:c", bolt, false},
+		{"c:c t!land", temple, false},
+		{"c!c", bolt, true},
+		{"c!c", solRing, false},
+		{"colors>=2", charm, true},
+		{"colors=3", charm, true},
+		{"colors>=2", bolt, false},
+		{"colors=0", solRing, true},
+		{"c:m colors<3", charm, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestColorNames(t *testing.T) {
+	for _, c := range []struct{ named, letters string }{
+		{"c:izzet", "c:ur"},
+		{"c:Izzet", "c:ur"},
+		{"c!golgari", "c!bg"},
+		{"c=azorius", "c=wu"},
+		{"id:bant", "id:gwu"},
+		{"id:jeskai", "id:urw"},
+		{"id:wubrg", "id:wubrg"},
+	} {
+		got, want := ParseQuery(c.named), ParseQuery(c.letters)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q parsed to %+v; want %+v, as for %q", c.named, got, want, c.letters)
+		}
+	}
+
+	q := ParseQuery("id:bant")
+	if want := []string{"g", "w", "u"}; !reflect.DeepEqual(q.ColorIdentity, want) {
+		t.Errorf("id:bant: got identity %q, want %q", q.ColorIdentity, want)
+	}
+	izzet := &Card{Name: "Izzet Charm", Colors: []string{"Blue", "Red"}}
+	if !ParseQuery("c:izzet").Match(izzet) || !ParseQuery("c:ur").Match(izzet) {
+		t.Errorf("c:izzet and c:ur should both match Izzet Charm")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	corpus := testCorpus(testCards...)
+	q := &Query{
+		Type:  []string{"merfolk"},
+		Color: []string{"u"},
+		CMC:   []CMCConstraint{{"<", 5}},
+	}
+	got := corpus.Search(q)
+	if len(got) != 1 || got[0].Name != "True-Name Nemesis" {
+		t.Errorf("got %v, want True-Name Nemesis", got)
+	}
+	if got := corpus.Search(&Query{}); len(got) != len(testCards) {
+		t.Errorf("empty query: got %d cards, want %d", len(got), len(testCards))
+	}
+}
+
+func TestQueryNameFolding(t *testing.T) {
+	jace := &Card{Name: "Jace Beleren"}
+	vial := &Card{Name: "Æther Vial"}
+	tnn := &Card{Name: "True-Name Nemesis"}
+	fireIce := &Card{Name: "Fire // Ice"}
+
+	for _, c := range []struct {
+		q     string
+		card  *Card
+		match bool
+	}{
+		{"jacebeleren", jace, true},
+		{"JaceBel", jace, true},
+		{"aether", vial, true},
+		{"æther", vial, true},
+		{"aethervial", vial, true},
+		{"truename", tnn, true},
+		{"true-name", tnn, true},
+		{"fireice", fireIce, true},
+		{"-aether", vial, false},
+		{"jaceb", vial, false},
+	} {
+		if got := ParseQuery(c.q).Match(c.card); got != c.match {
+			t.Errorf("%q on %s: got match %v, want %v", c.q, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestQueryExactName(t *testing.T) {
+	corpus := testCorpus(
+		&Card{Name: "Island", Type: "Basic Land — Island"},
+		&Card{Name: "Snow-Covered Island", Type: "Basic Snow Land — Island"},
+		&Card{Name: "Island Sanctuary", Type: "Enchantment"},
+		&Card{Name: "Lightning Bolt", Type: "Instant"},
+		&Card{Name: "Æther Vial", Type: "Artifact"},
+		&Card{Name: "Fire // Ice", Names: []string{"Fire", "Ice"}, Type: "Instant // Instant"},
+	)
+	for _, c := range []struct {
+		q    string
+		want []string
+	}{
+		{"!Island", []string{"Island"}},
+		{"!island", []string{"Island"}},
+		{"Island", []string{"Island", "Island Sanctuary", "Snow-Covered Island"}},
+		{`!"Lightning Bolt"`, []string{"Lightning Bolt"}},
+		{"!Lightning Bolt", []string{"Lightning Bolt"}},
+		{"!lightning bolt t:instant", []string{"Lightning Bolt"}},
+		{"t:instant !lightning bolt", []string{"Lightning Bolt"}},
+		{"!island -snow", []string{"Island"}},
+		{"!island OR !ice", []string{"Fire // Ice", "Island"}},
+		{`!"Island" sanctuary`, nil},
+		{"!Bolt", nil},
+		{`!"æther vial"`, []string{"Æther Vial"}},
+		{"!ice", []string{"Fire // Ice"}},
+		{`!"fire // ice"`, []string{"Fire // Ice"}},
+	} {
+		got, err := corpus.QuerySorted(c.q, SortByName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, card := range got {
+			names = append(names, card.Name)
+		}
+		if !reflect.DeepEqual(names, c.want) {
+			t.Errorf("%s: got %q, want %q", c.q, names, c.want)
+		}
+	}
+}
+
+func TestQuerySensitivity(t *testing.T) {
+	vial := &Card{Name: "Aether Vial", Type: "Artifact", Text: "At the beginning of your upkeep, you may put a charge counter on Aether Vial."}
+	oldVial := &Card{Name: "Æther Vial", Type: "Artifact"}
+
+	for _, c := range []struct {
+		q                string
+		card             *Card
+		caseSens, accent bool
+		match            bool
+	}{
+		{"Æther", vial, false, false, true},
+		{"Æther", vial, false, true, false},
+		{"aether", oldVial, false, true, false},
+		{"Æther", oldVial, false, true, true},
+		{`!"æther vial"`, vial, false, false, true},
+		{`!"Æther Vial"`, vial, false, true, false},
+		{"vial", vial, true, false, false},
+		{"Vial", vial, true, false, true},
+		{"t:artifact", vial, true, false, false},
+		{"o:upkeep t:Artifact", vial, true, false, true},
+		{"t:creature OR AETHER", vial, true, false, false},
+		{"t:creature OR Aether", vial, true, false, true},
+	} {
+		q := ParseQuery(c.q)
+		q.CaseSensitive, q.AccentSensitive = c.caseSens, c.accent
+		if got := q.Match(c.card); got != c.match {
+			t.Errorf("%q (case %v, accent %v) on %s: got match %v, want %v", c.q, c.caseSens, c.accent, c.card.Name, got, c.match)
+		}
+	}
+}
+
+func TestMatchExplain(t *testing.T) {
+	angel := &Card{
+		Name:   "Serra Angel",
+		Colors: []string{"White"},
+		Type:   "Creature — Angel",
+		Text:   "Flying, vigilance",
+		CMC:    5,
+	}
+
+	for _, c := range []struct {
+		q     string
+		match bool
+		want  []string
+	}{
+		{
+			"o:flying t:creature",
+			true,
+			[]string{`rule text contains "flying"`, `type contains "creature"`},
+		},
+		{
+			"serra c:w cmc>=4 -t:land",
+			true,
+			[]string{`name contains "serra"`, `type doesn't contain "land"`, "color is white", "cmc >= 4"},
+		},
+		{
+			"t:instant OR o:vigilance",
+			true,
+			[]string{`rule text contains "vigilance"`},
+		},
+		{"o:flying t:instant", false, nil},
+	} {
+		match, why := ParseQuery(c.q).MatchExplain(angel)
+		if match != c.match {
+			t.Errorf("%q: got match %v, want %v", c.q, match, c.match)
+		}
+		if !reflect.DeepEqual(why, c.want) {
+			t.Errorf("%q: got reasons %q, want %q", c.q, why, c.want)
+		}
+	}
+}
+
+func TestQueryJSON(t *testing.T) {
+	corpus := testCorpus(&Card{
+		Name:          "Tarmogoyf",
+		ManaCost:      "{1}{G}",
+		CMC:           2,
+		Colors:        []string{"Green"},
+		ColorIdentity: []string{"G"},
+		Type:          "Creature — Lhurgoyf",
+		Types:         []string{"Creature"},
+		SubTypes:      []string{"Lhurgoyf"},
+		Rarity:        "Mythic Rare",
+		Text:          "Tarmogoyf's power is equal to the number of card types among cards in all graveyards and its toughness is equal to that number plus 1.",
+		Power:         "*",
+		Toughness:     "1+*",
+		Printings:     []string{"FUT", "MM2"},
+		Legalities:    []FormatLegality{{Format: "Modern", Legality: "Legal"}},
+		Rulings:       []Ruling{{Date: "2017-04-18", Text: "Tarmogoyf's ability works in all zones."}},
+	})
+
+	got, err := corpus.QueryJSON("tarmogoyf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, got, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("testdata/query.golden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indented.String() != strings.TrimSpace(string(want)) {
+		t.Errorf("got\n%s\nwant\n%s", indented.String(), want)
+	}
+
+	if got, err := corpus.QueryJSON("t:planeswalker"); err != nil || string(got) != "[]" {
+		t.Errorf("got %s, %v for no matches, want []", got, err)
+	}
+}
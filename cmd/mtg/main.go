@@ -0,0 +1,124 @@
+// Command mtg prints the cards matching a query, e.g.
+//
+//	mtg -sort cmc c:r t:goblin
+//
+// Cards are fetched from mtgjson.com, and kept in a cache file so later
+// runs don't have to wait on the network.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/broady/mtg/cards"
+)
+
+var (
+	limit     = flag.Int("limit", 0, "print at most this many cards; 0 means all")
+	asJSON    = flag.Bool("json", false, "print the cards as a JSON array")
+	sortOrder = flag.String("sort", "name", "sort by name, cmc or rarity")
+	cacheFile = flag.String("cache", defaultCacheFile(), "file to cache cards in; empty disables caching")
+	verbose   = flag.Bool("v", false, "log card store updates")
+	timeout   = flag.Duration("timeout", 2*time.Minute, "how long to wait for cards to load")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: mtg [flags] query...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	order, err := parseSort(*sortOrder)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := []cards.Option{cards.WithUpdateFrequency(0)}
+	if *cacheFile != "" {
+		if err := os.MkdirAll(filepath.Dir(*cacheFile), 0755); err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, cards.WithCacheFile(*cacheFile))
+	}
+	if !*verbose {
+		opts = append(opts, func(s *cards.Store) { s.Logger = log.New(ioutil.Discard, "", 0) })
+	}
+	store := cards.NewStore(opts...)
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	corpus, err := store.CardsContext(ctx)
+	if err != nil {
+		log.Fatalf("loading cards: %v", err)
+	}
+
+	if err := printQuery(os.Stdout, corpus, strings.Join(flag.Args(), " "), order, *limit, *asJSON); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// defaultCacheFile returns a path in the user's cache directory, or ""
+// if there isn't one.
+func defaultCacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mtg", "cards.json")
+}
+
+func parseSort(s string) (cards.SortOrder, error) {
+	switch strings.ToLower(s) {
+	case "name":
+		return cards.SortByName, nil
+	case "cmc":
+		return cards.SortByCMC, nil
+	case "rarity":
+		return cards.SortByRarity, nil
+	}
+	return 0, fmt.Errorf("unknown sort order %q; want name, cmc or rarity", s)
+}
+
+// printQuery writes the cards in corpus matching query to w, one name per
+// line or as a JSON array. A limit of 0 means no limit.
+func printQuery(w io.Writer, corpus *cards.Cards, query string, order cards.SortOrder, limit int, asJSON bool) error {
+	if _, err := cards.ParseQueryStrict(query); err != nil {
+		return fmt.Errorf("query %q: %v", query, err)
+	}
+	match, err := corpus.QuerySorted(query, order)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && len(match) > limit {
+		match = match[:limit]
+	}
+
+	if asJSON {
+		if match == nil {
+			match = []*cards.Card{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(match)
+	}
+	for _, c := range match {
+		if _, err := fmt.Fprintln(w, c.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
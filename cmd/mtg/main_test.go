@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/broady/mtg/cards"
+)
+
+func testCorpus() *cards.Cards {
+	m := map[string]*cards.Card{}
+	for _, c := range []*cards.Card{
+		{Name: "Goblin Guide", Colors: []string{"Red"}, Type: "Creature — Goblin", CMC: 1, Rarity: "rare"},
+		{Name: "Goblin Piledriver", Colors: []string{"Red"}, Type: "Creature — Goblin Warrior", CMC: 2, Rarity: "rare"},
+		{Name: "Krenko, Mob Boss", Colors: []string{"Red"}, Type: "Legendary Creature — Goblin Warrior", CMC: 4, Rarity: "mythic"},
+		{Name: "Mogg Fanatic", Colors: []string{"Red"}, Type: "Creature — Goblin", CMC: 1, Rarity: "common"},
+		{Name: "Counterspell", Colors: []string{"Blue"}, Type: "Instant", CMC: 2, Rarity: "common"},
+	} {
+		m[c.Name] = c
+	}
+	return cards.NewCards(m)
+}
+
+func TestPrintQuery(t *testing.T) {
+	corpus := testCorpus()
+	for _, c := range []struct {
+		query string
+		sort  string
+		limit int
+		want  string
+	}{
+		{"t:goblin", "name", 0, "Goblin Guide\nGoblin Piledriver\nKrenko, Mob Boss\nMogg Fanatic\n"},
+		{"t:goblin", "cmc", 0, "Goblin Guide\nMogg Fanatic\nGoblin Piledriver\nKrenko, Mob Boss\n"},
+		{"t:goblin", "rarity", 2, "Mogg Fanatic\nGoblin Guide\n"},
+		{"c:u", "name", 0, "Counterspell\n"},
+		{"t:elf", "name", 0, ""},
+	} {
+		order, err := parseSort(c.sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := printQuery(&buf, corpus, c.query, order, c.limit, false); err != nil {
+			t.Errorf("%q: %v", c.query, err)
+			continue
+		}
+		if got := buf.String(); got != c.want {
+			t.Errorf("%q sorted by %s: got\n%s\nwant\n%s", c.query, c.sort, got, c.want)
+		}
+	}
+
+	if err := printQuery(new(bytes.Buffer), corpus, "cmc>=x", cards.SortByName, 0, false); err == nil {
+		t.Error("got no error for a bad query")
+	}
+	if _, err := parseSort("power"); err == nil {
+		t.Error("got no error for an unknown sort order")
+	}
+}
+
+func TestPrintQueryJSON(t *testing.T) {
+	for _, c := range []struct {
+		query string
+		want  []string
+	}{
+		{"t:warrior", []string{"Goblin Piledriver", "Krenko, Mob Boss"}},
+		{"t:elf", []string{}},
+	} {
+		var buf bytes.Buffer
+		if err := printQuery(&buf, testCorpus(), c.query, cards.SortByName, 0, true); err != nil {
+			t.Fatal(err)
+		}
+		var got []*cards.Card
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("%q: %v\n%s", c.query, err, buf.Bytes())
+		}
+		if got == nil {
+			t.Errorf("%q: got %s, want a JSON array", c.query, buf.Bytes())
+		}
+		if len(got) != len(c.want) {
+			t.Fatalf("%q: got %d cards, want %d", c.query, len(got), len(c.want))
+		}
+		for i, card := range got {
+			if card.Name != c.want[i] {
+				t.Errorf("%q: card %d is %q, want %q", c.query, i, card.Name, c.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package deck
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// ParseCSV reads a deck in tappedout's CSV export format, with a header
+// row and a row per entry. The Board, Qty and Name columns are required;
+// Printing, Foil, Alter, Signed, Condition, Language and Cmdr are used if
+// present. Column names are matched ignoring case, and columns can be in
+// any order. Entries marked as commanders must be in the mainboard.
+func ParseCSV(r io.Reader) (*Deck, error) {
+	rows, err := csvToMapSlice(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("empty deck")
+	}
+	// Other columns are optional, so tappedout can add or reorder them.
+	for _, header := range []string{"Board", "Qty", "Name"} {
+		if _, ok := rows[0][header]; !ok {
+			return nil, fmt.Errorf("unknown CSV formatting: missing header %q", header)
+		}
+	}
+
+	deck := &Deck{}
+	for _, row := range rows[1:] {
+		qty, err := strconv.Atoi(row["Qty"])
+		if err != nil {
+			return nil, fmt.Errorf("bad quantity: %+v", row)
+		}
+
+		if strings.TrimSpace(row["Name"]) == "" {
+			return nil, fmt.Errorf("missing card name: %+v", row)
+		}
+		entry := &Entry{
+			Quantity: qty,
+			CardName: strings.TrimSpace(row["Name"]),
+			Printing: row["Printing"],
+			Foil:     row["Foil"] != "",
+			Alter:    row["Alter"] != "",
+			Signed:   row["Signed"] != "",
+
+			Condition: row["Condition"],
+			Language:  row["Language"],
+
+			Commander: row["Cmdr"] != "" && row["Cmdr"] != "False",
+		}
+
+		switch row["Board"] {
+		case "main":
+			deck.Mainboard = append(deck.Mainboard, entry)
+			if entry.Commander {
+				deck.Commanders = append(deck.Commanders, entry)
+			}
+		case "side":
+			deck.Sideboard = append(deck.Sideboard, entry)
+		case "maybe":
+			deck.Maybeboard = append(deck.Maybeboard, entry)
+		case "acquire":
+			deck.Acquireboard = append(deck.Acquireboard, entry)
+		default:
+			return nil, fmt.Errorf("bad board: %+v", row)
+		}
+	}
+	return deck, nil
+}
+
+// csvHeaders are the CSV columns we know, keyed by lower-case spelling.
+// tappedout currently spells Language as "Languange".
+var csvHeaders = map[string]string{
+	"board":     "Board",
+	"qty":       "Qty",
+	"quantity":  "Qty",
+	"name":      "Name",
+	"printing":  "Printing",
+	"foil":      "Foil",
+	"alter":     "Alter",
+	"signed":    "Signed",
+	"condition": "Condition",
+	"language":  "Language",
+	"languange": "Language",
+	"cmdr":      "Cmdr",
+}
+
+// canonicalHeader returns the canonical name of a CSV column, ignoring
+// case and known misspellings. Unknown columns are returned as is.
+func canonicalHeader(h string) string {
+	if c, ok := csvHeaders[strings.ToLower(strings.TrimSpace(h))]; ok {
+		return c
+	}
+	return h
+}
+
+// csvToMapSlice reads CSV rows into maps keyed by canonical column name,
+// including the header row itself.
+func csvToMapSlice(r io.Reader) ([]map[string]string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		if len(b) > 100 {
+			return nil, fmt.Errorf("csv.Read: %v; \n%s <snip>", err, b[:99])
+		}
+		return nil, fmt.Errorf("csv.Read: %v; \n%s", err, b)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	var resp []map[string]string
+	for _, row := range rows {
+		mapped := map[string]string{}
+		for i, cell := range row {
+			mapped[canonicalHeader(rows[0][i])] = cell
+		}
+		resp = append(resp, mapped)
+	}
+	return resp, nil
+}
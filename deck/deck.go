@@ -0,0 +1,160 @@
+// Package deck is the deck model shared by the deck importers, such as
+// packages tappedout and moxfield, with plain-text import and export.
+package deck
+
+import (
+	"strings"
+
+	"github.com/broady/mtg/cards"
+)
+
+type Deck struct {
+	Meta Meta
+
+	Mainboard    []*Entry
+	Sideboard    []*Entry
+	Maybeboard   []*Entry
+	Acquireboard []*Entry
+	Commanders   []*Entry
+
+	// Companion is the deck's companion, usually also in the sideboard,
+	// or nil if it has none.
+	Companion *Entry
+}
+
+type Entry struct {
+	Quantity            int
+	CardName            string
+	Printing            string
+	Foil, Alter, Signed bool
+
+	// Condition and Language describe a physical card, such as "NM" and
+	// "EN". They are empty if unspecified.
+	Condition string
+	Language  string
+
+	Commander bool
+}
+
+// Meta describes a deck. Fields are best-effort, as provided by the deck's
+// host, and may be empty.
+type Meta struct {
+	Name        string
+	Author      string
+	Format      string
+	Description string
+	URL         string
+}
+
+// MainboardCount returns the number of cards in the mainboard. Commanders
+// are part of the mainboard, so a legal Commander deck has 100.
+func (d *Deck) MainboardCount() int {
+	return count(d.Mainboard)
+}
+
+// SideboardCount returns the number of cards in the sideboard.
+func (d *Deck) SideboardCount() int {
+	return count(d.Sideboard)
+}
+
+// TotalCount returns the number of cards in the mainboard and sideboard.
+// Maybeboard and acquireboard cards aren't part of the deck.
+func (d *Deck) TotalCount() int {
+	return d.MainboardCount() + d.SideboardCount()
+}
+
+// UniqueCount returns the number of distinct card names, ignoring case,
+// in the mainboard and sideboard.
+func (d *Deck) UniqueCount() int {
+	names := map[string]bool{}
+	for _, board := range [][]*Entry{d.Mainboard, d.Sideboard} {
+		for _, e := range board {
+			names[strings.ToLower(e.CardName)] = true
+		}
+	}
+	return len(names)
+}
+
+func count(entries []*Entry) int {
+	n := 0
+	for _, e := range entries {
+		n += e.Quantity
+	}
+	return n
+}
+
+// DuplicateViolations returns the cards in the mainboard and sideboard
+// with more copies than a format allows: one if singleton is true, as in
+// Commander, and four otherwise. Basic lands, including snow-covered ones
+// and Wastes, are unlimited; see cards.IsBasicLand. Copies are counted
+// across entries, so each returned Entry has the card's total Quantity.
+func (d *Deck) DuplicateViolations(singleton bool) []Entry {
+	limit := 4
+	if singleton {
+		limit = 1
+	}
+
+	var (
+		order  []string
+		totals = map[string]*Entry{}
+	)
+	for _, board := range [][]*Entry{d.Mainboard, d.Sideboard} {
+		for _, e := range board {
+			key := strings.ToLower(e.CardName)
+			if totals[key] == nil {
+				totals[key] = &Entry{CardName: e.CardName}
+				order = append(order, key)
+			}
+			totals[key].Quantity += e.Quantity
+		}
+	}
+
+	var violations []Entry
+	for _, key := range order {
+		if e := totals[key]; e.Quantity > limit && !cards.IsBasicLand(e.CardName) {
+			violations = append(violations, *e)
+		}
+	}
+	return violations
+}
+
+// Consolidate merges entries for the same card on the same board,
+// ignoring case, into the first such entry by summing their quantities.
+// The first entry's printing and flags are kept. Commanders and Companion
+// are updated to refer to the merged entries.
+func (d *Deck) Consolidate() {
+	merged := map[*Entry]*Entry{}
+	for _, board := range []*[]*Entry{&d.Mainboard, &d.Sideboard, &d.Maybeboard, &d.Acquireboard} {
+		first := map[string]*Entry{}
+		var out []*Entry
+		for _, e := range *board {
+			key := strings.ToLower(e.CardName)
+			f := first[key]
+			if f == nil {
+				first[key] = e
+				out = append(out, e)
+				continue
+			}
+			f.Quantity += e.Quantity
+			f.Commander = f.Commander || e.Commander
+			merged[e] = f
+		}
+		*board = out
+	}
+
+	var commanders []*Entry
+	seen := map[*Entry]bool{}
+	for _, e := range d.Commanders {
+		if m := merged[e]; m != nil {
+			e = m
+		}
+		if !seen[e] {
+			seen[e] = true
+			commanders = append(commanders, e)
+		}
+	}
+	d.Commanders = commanders
+	if m := merged[d.Companion]; m != nil {
+		d.Companion = m
+	}
+}
@@ -0,0 +1,93 @@
+package deck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCounts(t *testing.T) {
+	commander := &Entry{Quantity: 1, CardName: "Krenko, Mob Boss", Commander: true}
+	d := &Deck{
+		Mainboard: []*Entry{
+			commander,
+			{Quantity: 1, CardName: "Goblin Matron"},
+			{Quantity: 60, CardName: "Goblin Token"},
+			{Quantity: 38, CardName: "Mountain"},
+		},
+		Sideboard:  []*Entry{{Quantity: 2, CardName: "Pyroblast"}, {Quantity: 1, CardName: "mountain"}},
+		Maybeboard: []*Entry{{Quantity: 1, CardName: "Goblin Chieftain"}},
+		Commanders: []*Entry{commander},
+	}
+	if got := d.MainboardCount(); got != 100 {
+		t.Errorf("MainboardCount() = %d, want 100", got)
+	}
+	if got := d.SideboardCount(); got != 3 {
+		t.Errorf("SideboardCount() = %d, want 3", got)
+	}
+	if got := d.TotalCount(); got != 103 {
+		t.Errorf("TotalCount() = %d, want 103", got)
+	}
+	if got := d.UniqueCount(); got != 5 {
+		t.Errorf("UniqueCount() = %d, want 5", got)
+	}
+}
+
+func TestDuplicateViolations(t *testing.T) {
+	d := &Deck{
+		Mainboard: []*Entry{
+			{Quantity: 4, CardName: "Lightning Bolt", Printing: "M10"},
+			{Quantity: 3, CardName: "Lava Spike"},
+			{Quantity: 20, CardName: "Mountain"},
+		},
+		Sideboard: []*Entry{{Quantity: 1, CardName: "Lightning Bolt", Printing: "A25"}},
+	}
+	got := d.DuplicateViolations(false)
+	if want := []Entry{{Quantity: 5, CardName: "Lightning Bolt"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("constructed: got %+v, want %+v", got, want)
+	}
+
+	d = &Deck{Mainboard: []*Entry{
+		{Quantity: 1, CardName: "Sol Ring"},
+		{Quantity: 2, CardName: "Goblin Matron"},
+		{Quantity: 30, CardName: "Mountain"},
+		{Quantity: 5, CardName: "Wastes"},
+		{Quantity: 6, CardName: "Snow-Covered Mountain"},
+		{Quantity: 2, CardName: "snow-covered forest"},
+		{Quantity: 2, CardName: "Snow-Covered Wastes"},
+	}}
+	got = d.DuplicateViolations(true)
+	if want := []Entry{{Quantity: 2, CardName: "Goblin Matron"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("singleton: got %+v, want %+v", got, want)
+	}
+	if got := d.DuplicateViolations(false); got != nil {
+		t.Errorf("got %+v for a legal constructed deck, want none", got)
+	}
+}
+
+func TestConsolidate(t *testing.T) {
+	commander := &Entry{Quantity: 1, CardName: "Krenko, Mob Boss", Commander: true}
+	d := &Deck{
+		Mainboard: []*Entry{
+			{Quantity: 2, CardName: "Island", Printing: "M20"},
+			commander,
+			{Quantity: 2, CardName: "island", Printing: "ZEN", Foil: true},
+		},
+		Sideboard:  []*Entry{{Quantity: 1, CardName: "Island"}},
+		Commanders: []*Entry{commander},
+	}
+	d.Consolidate()
+
+	want := []*Entry{
+		{Quantity: 4, CardName: "Island", Printing: "M20"},
+		commander,
+	}
+	if !reflect.DeepEqual(d.Mainboard, want) {
+		t.Errorf("got mainboard %+v, want %+v", d.Mainboard, want)
+	}
+	if len(d.Sideboard) != 1 || d.Sideboard[0].Quantity != 1 {
+		t.Errorf("got sideboard %+v, want it unchanged", d.Sideboard)
+	}
+	if len(d.Commanders) != 1 || d.Commanders[0] != commander {
+		t.Errorf("got commanders %+v", d.Commanders)
+	}
+}
@@ -0,0 +1,109 @@
+package deck
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// decklistLineRE matches "4 Lightning Bolt", "4x Lightning Bolt" and the
+// Arena format "4 Lightning Bolt (M10) 146".
+var decklistLineRE = regexp.MustCompile(`^(\d+)x?\s+(.+?)(?:\s+\(([A-Za-z0-9]+)\)(?:\s+\S+)?)?$`)
+
+// ParseDecklist reads a plain-text decklist of "QTY Cardname" lines, such
+// as the output of Decklist or ArenaExport. Lines starting with "//" and
+// blank lines are ignored. "Commander", "Companion", "Deck", "Sideboard"
+// and "Maybeboard" lines start a new section; a blank line ends a
+// Commander or Companion section, as in the output of Decklist.
+// Commanders are also added to the mainboard, and the companion to the
+// sideboard unless it is already there. The "Name" line of an Arena
+// "About" section sets Meta.Name.
+func ParseDecklist(r io.Reader) (*Deck, error) {
+	deck := &Deck{}
+	board := &deck.Mainboard
+	commander, companion, about := false, false, false
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			if commander || companion || about {
+				board, commander, companion, about = &deck.Mainboard, false, false, false
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSuffix(line, ":")) {
+		case "commander", "commanders":
+			board, commander, companion = &deck.Mainboard, true, false
+			continue
+		case "companion":
+			commander, companion = false, true
+			continue
+		case "deck", "main", "mainboard":
+			board, commander, companion = &deck.Mainboard, false, false
+			continue
+		case "sideboard":
+			board, commander, companion = &deck.Sideboard, false, false
+			continue
+		case "maybeboard":
+			board, commander, companion = &deck.Maybeboard, false, false
+			continue
+		case "about":
+			about = true
+			continue
+		}
+		if about {
+			if name := strings.TrimPrefix(line, "Name "); name != line {
+				deck.Meta.Name = strings.TrimSpace(name)
+			}
+			continue
+		}
+
+		parts := decklistLineRE.FindStringSubmatch(line)
+		if parts == nil {
+			return nil, fmt.Errorf("line %d: bad decklist entry %q", n, line)
+		}
+		qty, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad quantity %q", n, parts[1])
+		}
+		entry := &Entry{
+			Quantity:  qty,
+			CardName:  parts[2],
+			Printing:  parts[3],
+			Commander: commander,
+		}
+		if companion {
+			deck.Companion = entry
+			continue
+		}
+		*board = append(*board, entry)
+		if commander {
+			deck.Commanders = append(deck.Commanders, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if deck.Companion != nil {
+		// Arena lists the companion in the sideboard too.
+		found := false
+		for _, e := range deck.Sideboard {
+			if strings.EqualFold(e.CardName, deck.Companion.CardName) {
+				deck.Companion, found = e, true
+				break
+			}
+		}
+		if !found {
+			deck.Sideboard = append(deck.Sideboard, deck.Companion)
+		}
+	}
+	return deck, nil
+}
@@ -0,0 +1,80 @@
+package deck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDecklist(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		list string
+		want *Deck
+	}{
+		{
+			"main only",
+			`// Burn
+4 Lightning Bolt
+4x Lava Spike
+
+20 Mountain
+`,
+			&Deck{Mainboard: []*Entry{
+				{Quantity: 4, CardName: "Lightning Bolt"},
+				{Quantity: 4, CardName: "Lava Spike"},
+				{Quantity: 20, CardName: "Mountain"},
+			}},
+		},
+		{
+			"sideboard",
+			`4 Lightning Bolt (M10) 146
+20 Mountain
+
+Sideboard:
+3 Smash to Smithereens
+`,
+			&Deck{
+				Mainboard: []*Entry{
+					{Quantity: 4, CardName: "Lightning Bolt", Printing: "M10"},
+					{Quantity: 20, CardName: "Mountain"},
+				},
+				Sideboard: []*Entry{{Quantity: 3, CardName: "Smash to Smithereens"}},
+			},
+		},
+	} {
+		got, err := ParseDecklist(strings.NewReader(c.list))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestParseDecklistCommander(t *testing.T) {
+	want := testDeck()
+	for _, e := range want.Mainboard {
+		e.Printing, e.Foil = "", false
+	}
+	for _, e := range want.Sideboard {
+		e.Printing = ""
+	}
+
+	got, err := ParseDecklist(strings.NewReader(want.Decklist()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if len(got.Commanders) != 1 || got.Commanders[0] != got.Mainboard[0] || !got.Commanders[0].Commander {
+		t.Errorf("commander should also be the first mainboard entry: %+v", got)
+	}
+
+	if _, err := ParseDecklist(strings.NewReader("4 Lightning Bolt\nLightning Bolt\n")); err == nil {
+		t.Errorf("got no error for a line without a quantity")
+	}
+}
@@ -0,0 +1,73 @@
+package deck
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Decklist returns the deck as a plain-text decklist, one "QTY Cardname"
+// line per entry. Commanders, if any, come first under a "Commander"
+// header, followed by a blank line and the rest of the mainboard. The
+// sideboard follows under a "Sideboard" header.
+func (d *Deck) Decklist() string {
+	var buf bytes.Buffer
+	if len(d.Commanders) > 0 {
+		buf.WriteString("Commander\n")
+		writeEntries(&buf, d.Commanders, "%d %s\n")
+		buf.WriteString("\n")
+	}
+	writeEntries(&buf, mainboardWithoutCommanders(d), "%d %s\n")
+	if len(d.Sideboard) > 0 {
+		buf.WriteString("\nSideboard\n")
+		writeEntries(&buf, d.Sideboard, "%d %s\n")
+	}
+	return buf.String()
+}
+
+// ArenaExport returns the deck in the MTG Arena import format, with
+// "Commander", "Deck" and "Sideboard" sections of lines such as
+// "4 Lightning Bolt (M10)". Each entry needs a Quantity and CardName; its
+// Printing, if set, is used as the set code. Collector numbers aren't
+// known, so they are left out, which Arena accepts.
+func (d *Deck) ArenaExport() string {
+	var buf bytes.Buffer
+	section := func(header string, entries []*Entry) {
+		if len(entries) == 0 {
+			return
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(header + "\n")
+		for _, e := range entries {
+			fmt.Fprintf(&buf, "%d %s", e.Quantity, e.CardName)
+			if e.Printing != "" {
+				fmt.Fprintf(&buf, " (%s)", strings.ToUpper(e.Printing))
+			}
+			buf.WriteString("\n")
+		}
+	}
+	section("Commander", d.Commanders)
+	section("Deck", mainboardWithoutCommanders(d))
+	section("Sideboard", d.Sideboard)
+	return buf.String()
+}
+
+// mainboardWithoutCommanders returns the mainboard entries that aren't
+// commanders.
+func mainboardWithoutCommanders(d *Deck) []*Entry {
+	var entries []*Entry
+	for _, e := range d.Mainboard {
+		if !e.Commander {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func writeEntries(buf *bytes.Buffer, entries []*Entry, format string) {
+	for _, e := range entries {
+		fmt.Fprintf(buf, format, e.Quantity, e.CardName)
+	}
+}
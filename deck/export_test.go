@@ -0,0 +1,59 @@
+package deck
+
+import "testing"
+
+func testDeck() *Deck {
+	commander := &Entry{Quantity: 1, CardName: "Krenko, Mob Boss", Printing: "M13", Commander: true}
+	return &Deck{
+		Mainboard: []*Entry{
+			commander,
+			{Quantity: 1, CardName: "Goblin Matron", Printing: "UMA"},
+			{Quantity: 30, CardName: "Mountain", Printing: "M20", Foil: true},
+		},
+		Sideboard: []*Entry{
+			{Quantity: 2, CardName: "Pyroblast", Printing: "ICE"},
+		},
+		Commanders: []*Entry{commander},
+	}
+}
+
+func TestDecklist(t *testing.T) {
+	want := `Commander
+1 Krenko, Mob Boss
+
+1 Goblin Matron
+30 Mountain
+
+Sideboard
+2 Pyroblast
+`
+	if got := testDeck().Decklist(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	deck := &Deck{Mainboard: []*Entry{{Quantity: 4, CardName: "Lightning Bolt"}}}
+	if got, want := deck.Decklist(), "4 Lightning Bolt\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestArenaExport(t *testing.T) {
+	want := `Commander
+1 Krenko, Mob Boss (M13)
+
+Deck
+1 Goblin Matron (UMA)
+30 Mountain (M20)
+
+Sideboard
+2 Pyroblast (ICE)
+`
+	if got := testDeck().ArenaExport(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	deck := &Deck{Mainboard: []*Entry{{Quantity: 4, CardName: "Lightning Bolt", Printing: "m10"}, {Quantity: 20, CardName: "Mountain"}}}
+	if got, want := deck.ArenaExport(), "Deck\n4 Lightning Bolt (M10)\n20 Mountain\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package deck
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// DeckFromFile reads the deck in the file at path, which may be a plain
+// decklist, an Arena export, or a tappedout CSV export. The format is
+// detected from the first non-blank line: a CSV header with Board, Qty
+// and Name columns means CSV, and anything else is read by
+// ParseDecklist.
+func DeckFromFile(path string) (*Deck, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	parse := ParseDecklist
+	if isCSVHeader(firstLine(b)) {
+		parse = ParseCSV
+	}
+	d, err := parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return d, nil
+}
+
+// firstLine returns the first non-blank line of b.
+func firstLine(b []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// isCSVHeader reports whether line is the header of a CSV deck export.
+func isCSVHeader(line string) bool {
+	fields, err := csv.NewReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return false
+	}
+	have := map[string]bool{}
+	for _, f := range fields {
+		have[canonicalHeader(f)] = true
+	}
+	return have["Board"] && have["Qty"] && have["Name"]
+}
@@ -0,0 +1,97 @@
+package deck
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDeckFromFile(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		contents string
+		want     *Deck
+	}{
+		{
+			"plain",
+			`4 Lightning Bolt
+20 Mountain
+
+Sideboard
+3 Pyroblast
+`,
+			&Deck{
+				Mainboard: []*Entry{
+					{Quantity: 4, CardName: "Lightning Bolt"},
+					{Quantity: 20, CardName: "Mountain"},
+				},
+				Sideboard: []*Entry{{Quantity: 3, CardName: "Pyroblast"}},
+			},
+		},
+		{
+			"tappedout csv",
+			`Board,Qty,Name,Printing,Foil,Alter,Signed,Condition,Languange,Cmdr
+main,1,"Krenko, Mob Boss",DDN,,,,,,True
+main,40,Mountain,,,,,,,False
+side,1,Pyroblast,ICE,foil,,,NM,EN,False
+`,
+			func() *Deck {
+				krenko := &Entry{Quantity: 1, CardName: "Krenko, Mob Boss", Printing: "DDN", Commander: true}
+				return &Deck{
+					Mainboard:  []*Entry{krenko, {Quantity: 40, CardName: "Mountain"}},
+					Sideboard:  []*Entry{{Quantity: 1, CardName: "Pyroblast", Printing: "ICE", Foil: true, Condition: "NM", Language: "EN"}},
+					Commanders: []*Entry{krenko},
+				}
+			}(),
+		},
+		{
+			"arena",
+			`About
+Name Lurrus Burn
+
+Companion
+1 Lurrus of the Dream-Den (IKO) 226
+
+Deck
+4 Lightning Bolt (M10) 146
+20 Mountain (M21) 271
+
+Sideboard
+1 Lurrus of the Dream-Den (IKO) 226
+`,
+			func() *Deck {
+				lurrus := &Entry{Quantity: 1, CardName: "Lurrus of the Dream-Den", Printing: "IKO"}
+				return &Deck{
+					Meta: Meta{Name: "Lurrus Burn"},
+					Mainboard: []*Entry{
+						{Quantity: 4, CardName: "Lightning Bolt", Printing: "M10"},
+						{Quantity: 20, CardName: "Mountain", Printing: "M21"},
+					},
+					Sideboard: []*Entry{lurrus},
+					Companion: lurrus,
+				}
+			}(),
+		},
+	} {
+		path := filepath.Join(t.TempDir(), "deck.txt")
+		if err := ioutil.WriteFile(path, []byte(c.contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := DeckFromFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+		if got.Companion != nil && got.Companion != got.Sideboard[0] {
+			t.Errorf("%s: Companion isn't the sideboard entry", c.name)
+		}
+	}
+
+	if _, err := DeckFromFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("missing file: got no error")
+	}
+}
@@ -0,0 +1,69 @@
+package deck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// An Importer fetches the deck at a URL.
+type Importer func(ctx context.Context, deckURL string) (*Deck, error)
+
+var (
+	importersMu sync.RWMutex
+	importers   = map[string]Importer{}
+)
+
+// Register makes an importer available to FromURL for decks on host,
+// such as "tappedout.net". It is usually called from the init function
+// of the importer's package, so that importing the package, e.g.
+//
+//	import _ "github.com/broady/mtg/moxfield"
+//
+// is enough to support its host.
+func Register(host string, fn Importer) {
+	importersMu.Lock()
+	defer importersMu.Unlock()
+	importers[strings.ToLower(host)] = fn
+}
+
+// Host returns u's host as FromURL matches it: lowercased, without a
+// port or a "www." prefix. Importers should check URLs with it, so that
+// they accept every URL FromURL dispatches to them.
+func Host(u *url.URL) string {
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// FromURL fetches the deck at deckURL using the importer registered for
+// its host. A "www." prefix on the host is ignored.
+func FromURL(ctx context.Context, deckURL string) (*Deck, error) {
+	u, err := url.Parse(deckURL)
+	if err != nil {
+		return nil, err
+	}
+	host := Host(u)
+
+	importersMu.RLock()
+	fn := importers[host]
+	var hosts []string
+	for h := range importers {
+		hosts = append(hosts, h)
+	}
+	importersMu.RUnlock()
+
+	if fn != nil {
+		return fn(ctx, deckURL)
+	}
+	if host == "" {
+		return nil, errors.New("deck: not a URL; want e.g. https://tappedout.net/mtg-decks/...")
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("deck: unsupported host %q; no importers are registered", host)
+	}
+	sort.Strings(hosts)
+	return nil, fmt.Errorf("deck: unsupported host %q; want one of %s", host, strings.Join(hosts, ", "))
+}
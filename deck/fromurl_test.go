@@ -0,0 +1,62 @@
+package deck
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFromURL(t *testing.T) {
+	var got []string
+	for _, host := range []string{"tappedout.net", "moxfield.com", "archidekt.com"} {
+		host := host
+		Register(host, func(ctx context.Context, deckURL string) (*Deck, error) {
+			got = append(got, host)
+			return &Deck{Meta: Meta{URL: deckURL}}, nil
+		})
+	}
+
+	for _, c := range []struct {
+		url, host string
+	}{
+		{"http://tappedout.net/mtg-decks/krenko/", "tappedout.net"},
+		{"https://www.moxfield.com/decks/abc123", "moxfield.com"},
+		{"https://Archidekt.com/decks/1234/krenko", "archidekt.com"},
+	} {
+		got = nil
+		d, err := FromURL(context.Background(), c.url)
+		if err != nil {
+			t.Errorf("FromURL(%q): %v", c.url, err)
+			continue
+		}
+		if len(got) != 1 || got[0] != c.host || d.Meta.URL != c.url {
+			t.Errorf("FromURL(%q) dispatched to %q, want %q", c.url, got, c.host)
+		}
+	}
+
+	_, err := FromURL(context.Background(), "https://deckstats.net/decks/1")
+	if err == nil || !strings.Contains(err.Error(), `"deckstats.net"`) || !strings.Contains(err.Error(), "archidekt.com, moxfield.com, tappedout.net") {
+		t.Errorf("got error %v for an unknown host, want one naming it and the supported hosts", err)
+	}
+	if _, err := FromURL(context.Background(), "krenko"); err == nil {
+		t.Error("got no error for a non-URL")
+	}
+}
+
+func TestHost(t *testing.T) {
+	for _, c := range []struct{ url, want string }{
+		{"https://tappedout.net/mtg-decks/krenko/", "tappedout.net"},
+		{"https://WWW.TappedOut.net/mtg-decks/krenko/", "tappedout.net"},
+		{"https://moxfield.com:443/decks/abc123", "moxfield.com"},
+		{"krenko", ""},
+	} {
+		u, err := url.Parse(c.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Host(u); got != c.want {
+			t.Errorf("Host(%q) = %q, want %q", c.url, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package deck
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/broady/mtg/cards"
+)
+
+// TypeGroups are the groups of GroupedByType in display order. A card
+// with several types, such as an artifact creature, is in the first
+// group that matches.
+var TypeGroups = []string{
+	"Creatures", "Planeswalkers", "Battles", "Instants", "Sorceries",
+	"Artifacts", "Enchantments", "Lands", "Other", "Unknown",
+}
+
+// groupTypes maps each card type to its group, in the order of
+// precedence.
+var groupTypes = []struct{ typ, group string }{
+	{"Creature", "Creatures"},
+	{"Planeswalker", "Planeswalkers"},
+	{"Battle", "Battles"},
+	{"Instant", "Instants"},
+	{"Sorcery", "Sorceries"},
+	{"Artifact", "Artifacts"},
+	{"Enchantment", "Enchantments"},
+	{"Land", "Lands"},
+}
+
+// GroupedByType returns the mainboard entries grouped by card type, as
+// found in corpus, with each group sorted by name; see TypeGroups.
+// Entries for the same card are merged into one, so the returned entries
+// are copies. Cards missing from corpus are in the "Unknown" group, and
+// empty groups are left out.
+func (d *Deck) GroupedByType(corpus *cards.Cards) map[string][]*Entry {
+	groups := map[string][]*Entry{}
+	merged := map[string]*Entry{}
+	for _, e := range d.Mainboard {
+		name, group := e.CardName, "Unknown"
+		if card := corpus.LookupNormalized(e.CardName); card != nil {
+			name, group = card.Name, typeGroup(card)
+		}
+		key := strings.ToLower(name)
+		if m := merged[key]; m != nil {
+			m.Quantity += e.Quantity
+			m.Commander = m.Commander || e.Commander
+			continue
+		}
+		m := *e
+		m.CardName = name
+		merged[key] = &m
+		groups[group] = append(groups[group], &m)
+	}
+	for _, entries := range groups {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].CardName < entries[j].CardName })
+	}
+	return groups
+}
+
+// typeGroup returns the TypeGroups group of card.
+func typeGroup(card *cards.Card) string {
+	for _, gt := range groupTypes {
+		for _, t := range card.Types {
+			if strings.EqualFold(t, gt.typ) {
+				return gt.group
+			}
+		}
+	}
+	return "Other"
+}
@@ -0,0 +1,65 @@
+package deck
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/broady/mtg/cards"
+)
+
+func TestGroupedByType(t *testing.T) {
+	corpus := cards.NewCards(map[string]*cards.Card{
+		"Goblin Guide":               {Name: "Goblin Guide", Types: []string{"Creature"}},
+		"Ornithopter":                {Name: "Ornithopter", Types: []string{"Artifact", "Creature"}},
+		"Chandra, Torch of Defiance": {Name: "Chandra, Torch of Defiance", Types: []string{"Planeswalker"}},
+		"Lightning Bolt":             {Name: "Lightning Bolt", Types: []string{"Instant"}},
+		"Lava Spike":                 {Name: "Lava Spike", Types: []string{"Sorcery"}},
+		"Shrine of Burning Rage":     {Name: "Shrine of Burning Rage", Types: []string{"Artifact"}},
+		"Mountain":                   {Name: "Mountain", Types: []string{"Land"}, SuperTypes: []string{"Basic"}},
+		"Inspiring Vantage":          {Name: "Inspiring Vantage", Types: []string{"Land"}},
+		"Backup Plan":                {Name: "Backup Plan", Types: []string{"Conspiracy"}},
+	})
+	d := &Deck{
+		Mainboard: []*Entry{
+			{Quantity: 4, CardName: "Lightning Bolt"},
+			{Quantity: 4, CardName: "Lava Spike"},
+			{Quantity: 4, CardName: "Goblin Guide"},
+			{Quantity: 2, CardName: "Ornithopter"},
+			{Quantity: 1, CardName: "Chandra, Torch of Defiance"},
+			{Quantity: 2, CardName: "Shrine of Burning Rage"},
+			{Quantity: 10, CardName: "Mountain"},
+			{Quantity: 4, CardName: "Inspiring Vantage"},
+			{Quantity: 8, CardName: "mountain"},
+			{Quantity: 1, CardName: "Backup Plan"},
+			{Quantity: 1, CardName: "Lightning Blot"},
+		},
+		Sideboard: []*Entry{{Quantity: 3, CardName: "Pyroblast"}},
+	}
+	want := map[string][]*Entry{
+		"Creatures": {
+			{Quantity: 4, CardName: "Goblin Guide"},
+			{Quantity: 2, CardName: "Ornithopter"},
+		},
+		"Planeswalkers": {{Quantity: 1, CardName: "Chandra, Torch of Defiance"}},
+		"Instants":      {{Quantity: 4, CardName: "Lightning Bolt"}},
+		"Sorceries":     {{Quantity: 4, CardName: "Lava Spike"}},
+		"Artifacts":     {{Quantity: 2, CardName: "Shrine of Burning Rage"}},
+		"Lands": {
+			{Quantity: 4, CardName: "Inspiring Vantage"},
+			{Quantity: 18, CardName: "Mountain"},
+		},
+		"Other":   {{Quantity: 1, CardName: "Backup Plan"}},
+		"Unknown": {{Quantity: 1, CardName: "Lightning Blot"}},
+	}
+	got := d.GroupedByType(corpus)
+	if !reflect.DeepEqual(got, want) {
+		for _, g := range TypeGroups {
+			if !reflect.DeepEqual(got[g], want[g]) {
+				t.Errorf("%s: got %+v, want %+v", g, got[g], want[g])
+			}
+		}
+	}
+	if d.Mainboard[6].Quantity != 10 {
+		t.Errorf("GroupedByType modified the deck: Mountain quantity is %d, want 10", d.Mainboard[6].Quantity)
+	}
+}
@@ -0,0 +1,83 @@
+package deck
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/broady/mtg/cards"
+)
+
+// mtgoDeck is the MTGO .dek XML schema.
+type mtgoDeck struct {
+	XMLName              xml.Name   `xml:"Deck"`
+	XSD                  string     `xml:"xmlns:xsd,attr"`
+	XSI                  string     `xml:"xmlns:xsi,attr"`
+	NetDeckID            int        `xml:"NetDeckID"`
+	PreconstructedDeckID int        `xml:"PreconstructedDeckID"`
+	Cards                []mtgoCard `xml:"Cards"`
+}
+
+type mtgoCard struct {
+	Quantity  int    `xml:"Quantity,attr"`
+	Sideboard bool   `xml:"Sideboard,attr"`
+	Name      string `xml:"Name,attr"`
+}
+
+// MTGOExport returns the mainboard and sideboard in the MTGO .dek XML
+// format. MTGO needs exact card names, so each entry is looked up in
+// corpus and written with the card's name as MTGO spells it; if any
+// aren't found, MTGOExport returns an error listing them.
+func (d *Deck) MTGOExport(corpus *cards.Cards) ([]byte, error) {
+	var names []string
+	for _, entries := range [][]*Entry{d.Mainboard, d.Sideboard} {
+		for _, e := range entries {
+			names = append(names, e.CardName)
+		}
+	}
+	found, missing := corpus.LookupAll(names)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("deck: unknown cards: %s", strings.Join(missing, ", "))
+	}
+
+	dek := mtgoDeck{
+		XSD: "http://www.w3.org/2001/XMLSchema",
+		XSI: "http://www.w3.org/2001/XMLSchema-instance",
+	}
+	add := func(entries []*Entry, sideboard bool) {
+		for _, e := range entries {
+			dek.Cards = append(dek.Cards, mtgoCard{
+				Quantity:  e.Quantity,
+				Sideboard: sideboard,
+				Name:      mtgoName(found[e.CardName]),
+			})
+		}
+	}
+	add(d.Mainboard, false)
+	add(d.Sideboard, true)
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(dek); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// mtgoName returns the name MTGO uses for c: split cards such as
+// "Fire // Ice" are "Fire/Ice", and other multi-face cards go by their
+// front face.
+func mtgoName(c *cards.Card) string {
+	if len(c.Names) < 2 {
+		return c.Name
+	}
+	switch c.Layout {
+	case "split", "aftermath":
+		return strings.Join(c.Names, "/")
+	}
+	return c.Names[0]
+}
@@ -0,0 +1,57 @@
+package deck
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/broady/mtg/cards"
+)
+
+func mtgoCorpus() *cards.Cards {
+	fire := &cards.Card{Name: "Fire // Ice", Names: []string{"Fire", "Ice"}, Layout: "split"}
+	return cards.NewCards(map[string]*cards.Card{
+		"Lightning Bolt": {Name: "Lightning Bolt"},
+		"Mountain":       {Name: "Mountain"},
+		"Pyroblast":      {Name: "Pyroblast"},
+		"Fire // Ice":    fire,
+	})
+}
+
+func TestMTGOExport(t *testing.T) {
+	d := &Deck{
+		Mainboard: []*Entry{
+			{Quantity: 4, CardName: "lightning bolt"},
+			{Quantity: 2, CardName: "Fire // Ice"},
+			{Quantity: 18, CardName: "Mountain"},
+		},
+		Sideboard: []*Entry{{Quantity: 3, CardName: "Pyroblast"}},
+	}
+	got, err := d.MTGOExport(mtgoCorpus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("testdata/mtgo.golden.dek")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMTGOExportUnknown(t *testing.T) {
+	d := &Deck{
+		Mainboard: []*Entry{{Quantity: 4, CardName: "Lightning Blot"}, {Quantity: 18, CardName: "Mountain"}},
+		Sideboard: []*Entry{{Quantity: 1, CardName: "Pyroblat"}},
+	}
+	_, err := d.MTGOExport(mtgoCorpus())
+	if err == nil {
+		t.Fatal("got no error for unknown cards")
+	}
+	for _, name := range []string{"Lightning Blot", "Pyroblat"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q doesn't mention %q", err, name)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package deck
+
+import "strings"
+
+// EstimatePrice returns the price of the mainboard and sideboard, given
+// per-card prices keyed by card name, such as from TCGplayer or Scryfall.
+// Names are matched exactly, or failing that ignoring case. missing lists
+// the cards without a price, once each, in deck order; they add nothing
+// to total.
+func (d *Deck) EstimatePrice(prices map[string]float64) (total float64, missing []string) {
+	var folded map[string]float64
+	seen := map[string]bool{}
+	for _, board := range [][]*Entry{d.Mainboard, d.Sideboard} {
+		for _, e := range board {
+			price, ok := prices[e.CardName]
+			if !ok {
+				if folded == nil {
+					folded = make(map[string]float64, len(prices))
+					for name, p := range prices {
+						folded[strings.ToLower(name)] = p
+					}
+				}
+				price, ok = folded[strings.ToLower(e.CardName)]
+			}
+			if !ok {
+				if key := strings.ToLower(e.CardName); !seen[key] {
+					seen[key] = true
+					missing = append(missing, e.CardName)
+				}
+				continue
+			}
+			total += float64(e.Quantity) * price
+		}
+	}
+	return total, missing
+}
@@ -0,0 +1,39 @@
+package deck
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestEstimatePrice(t *testing.T) {
+	d := &Deck{
+		Mainboard: []*Entry{
+			{Quantity: 4, CardName: "Lightning Bolt"},
+			{Quantity: 4, CardName: "Goblin Guide"},
+			{Quantity: 2, CardName: "Unpriced Promo"},
+			{Quantity: 16, CardName: "Mountain"},
+		},
+		Sideboard:  []*Entry{{Quantity: 2, CardName: "pyroblast"}, {Quantity: 1, CardName: "unpriced promo"}},
+		Maybeboard: []*Entry{{Quantity: 4, CardName: "Black Lotus"}},
+	}
+	prices := map[string]float64{
+		"Lightning Bolt": 1.50,
+		"Goblin Guide":   3.25,
+		"Mountain":       0.10,
+		"Pyroblast":      0.75,
+		"Black Lotus":    20000,
+	}
+
+	total, missing := d.EstimatePrice(prices)
+	if want := 4*1.50 + 4*3.25 + 16*0.10 + 2*0.75; math.Abs(total-want) > 1e-9 {
+		t.Errorf("got total %v, want %v", total, want)
+	}
+	if want := []string{"Unpriced Promo"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("got missing %q, want %q", missing, want)
+	}
+
+	if total, missing := (&Deck{}).EstimatePrice(nil); total != 0 || missing != nil {
+		t.Errorf("empty deck: got %v, %q; want 0 and nothing missing", total, missing)
+	}
+}
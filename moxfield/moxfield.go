@@ -0,0 +1,159 @@
+// Package moxfield fetches decks from moxfield.com.
+package moxfield
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/broady/mtg/deck"
+)
+
+// APIURL is the base URL of Moxfield's public deck API.
+const APIURL = "https://api2.moxfield.com/v2/decks/all/"
+
+func init() {
+	deck.Register("moxfield.com", func(ctx context.Context, deckURL string) (*deck.Deck, error) {
+		return DeckFromURLContext(ctx, nil, deckURL)
+	})
+}
+
+func DeckFromURL(deckURL string) (*deck.Deck, error) {
+	return DeckFromURLContext(context.Background(), nil, deckURL)
+}
+
+// DeckFromURLContext fetches a deck given its URL, such as
+// "https://www.moxfield.com/decks/<id>", using ctx for the request. If
+// client is nil, http.DefaultClient is used.
+func DeckFromURLContext(ctx context.Context, client *http.Client, deckURL string) (*deck.Deck, error) {
+	id, err := deckID(deckURL)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, _ := http.NewRequest("GET", APIURL+url.PathEscape(id), nil)
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", "github.com_broady_mtg")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%q: non-OK response from moxfield: %s", id, resp.Status)
+	}
+
+	d, err := decodeDeck(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if d.Meta.URL == "" {
+		d.Meta.URL = "https://www.moxfield.com/decks/" + id
+	}
+	return d, nil
+}
+
+// deckID returns the public ID from a moxfield.com deck URL.
+func deckID(deckURL string) (string, error) {
+	u, err := url.Parse(deckURL)
+	if err != nil {
+		return "", err
+	}
+	if deck.Host(u) != "moxfield.com" {
+		return "", fmt.Errorf("must be a moxfield.com URL; got %q", u.Host)
+	}
+	id := strings.TrimPrefix(u.Path, "/decks/")
+	id = strings.TrimSuffix(id, "/")
+	if id == u.Path || id == "" || strings.Contains(id, "/") {
+		return "", errors.New("must be a deck URL")
+	}
+	return id, nil
+}
+
+// apiDeck is a deck as returned by the Moxfield API. Each board maps card
+// names to entries.
+type apiDeck struct {
+	Name          string
+	Description   string
+	Format        string
+	PublicURL     string
+	CreatedByUser struct {
+		UserName string
+	}
+
+	Mainboard  map[string]apiEntry
+	Sideboard  map[string]apiEntry
+	Maybeboard map[string]apiEntry
+	Commanders map[string]apiEntry
+	Companions map[string]apiEntry
+}
+
+type apiEntry struct {
+	Quantity int
+	IsFoil   bool
+	IsAlter  bool
+	Card     struct {
+		Name string
+		Set  string
+	}
+}
+
+func decodeDeck(r io.Reader) (*deck.Deck, error) {
+	var api apiDeck
+	if err := json.NewDecoder(r).Decode(&api); err != nil {
+		return nil, fmt.Errorf("moxfield: decoding deck: %v", err)
+	}
+
+	d := &deck.Deck{
+		Meta: deck.Meta{
+			Name:        api.Name,
+			Author:      api.CreatedByUser.UserName,
+			Format:      api.Format,
+			Description: api.Description,
+			URL:         api.PublicURL,
+		},
+		Mainboard:  entries(api.Mainboard),
+		Sideboard:  entries(api.Sideboard),
+		Maybeboard: entries(api.Maybeboard),
+		Commanders: entries(api.Commanders),
+	}
+	// Like tappedout, commanders are also part of the mainboard and the
+	// companion is part of the sideboard.
+	for _, e := range d.Commanders {
+		e.Commander = true
+	}
+	d.Mainboard = append(append([]*deck.Entry(nil), d.Commanders...), d.Mainboard...)
+	if companions := entries(api.Companions); len(companions) > 0 {
+		d.Companion = companions[0]
+		d.Sideboard = append(companions, d.Sideboard...)
+	}
+	return d, nil
+}
+
+// entries converts a board to entries sorted by card name.
+func entries(board map[string]apiEntry) []*deck.Entry {
+	var out []*deck.Entry
+	for name, e := range board {
+		if e.Card.Name != "" {
+			name = e.Card.Name
+		}
+		out = append(out, &deck.Entry{
+			Quantity: e.Quantity,
+			CardName: name,
+			Printing: strings.ToUpper(e.Card.Set),
+			Foil:     e.IsFoil,
+			Alter:    e.IsAlter,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CardName < out[j].CardName })
+	return out
+}
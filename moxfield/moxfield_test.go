@@ -0,0 +1,81 @@
+package moxfield
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestDeckID(t *testing.T) {
+	for _, c := range []struct {
+		url, want string
+	}{
+		{"https://www.moxfield.com/decks/abc123", "abc123"},
+		{"https://moxfield.com/decks/abc123/", "abc123"},
+		{"https://WWW.MoxField.com:443/decks/abc123", "abc123"},
+		{"https://www.moxfield.com/users/someone", ""},
+		{"https://tappedout.net/decks/abc123", ""},
+	} {
+		got, err := deckID(c.url)
+		if got != c.want || (err != nil) != (c.want == "") {
+			t.Errorf("deckID(%q) = %q, %v; want %q", c.url, got, err, c.want)
+		}
+	}
+}
+
+func TestDeckFromURLContext(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/deck.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write(b)
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+	client := &http.Client{Transport: rewriteTransport{target}}
+
+	deck, err := DeckFromURLContext(context.Background(), client, "https://www.moxfield.com/decks/abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v2/decks/all/abc123" {
+		t.Errorf("got request for %q", path)
+	}
+
+	main := 0
+	for _, e := range deck.Mainboard {
+		main += e.Quantity
+	}
+	if main != 33 {
+		t.Errorf("mainboard qty: got %d; want 33", main)
+	}
+	if len(deck.Commanders) != 1 || deck.Commanders[0] != deck.Mainboard[0] || !deck.Commanders[0].Commander {
+		t.Errorf("got commanders %+v, want Krenko first in the mainboard", deck.Commanders)
+	}
+	if c := deck.Commanders[0]; c.CardName != "Krenko, Mob Boss" || c.Printing != "M13" || !c.Foil {
+		t.Errorf("got commander %+v", c)
+	}
+	if deck.Companion == nil || deck.Companion.CardName != "Lurrus of the Dream-Den" || len(deck.Sideboard) != 2 {
+		t.Errorf("got companion %+v and sideboard %+v", deck.Companion, deck.Sideboard)
+	}
+	if len(deck.Maybeboard) != 1 {
+		t.Errorf("got maybeboard %+v, want 1 entry", deck.Maybeboard)
+	}
+	if deck.Meta.Name != "Krenko Tokens" || deck.Meta.Author != "goblinking" || deck.Meta.Format != "commander" {
+		t.Errorf("got meta %+v", deck.Meta)
+	}
+}
+
+type rewriteTransport struct{ target *url.URL }
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
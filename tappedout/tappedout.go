@@ -2,172 +2,299 @@ package tappedout
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/csv"
+	"context"
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"regexp"
-	"strconv"
 	"strings"
+	"time"
+
+	"github.com/broady/mtg/deck"
 )
 
-type Deck struct {
-	Mainboard    []*Entry
-	Sideboard    []*Entry
-	Maybeboard   []*Entry
-	Acquireboard []*Entry
-	Commanders   []*Entry
-}
+// Deck and Entry are shared by all deck importers; see package deck.
+type (
+	Deck  = deck.Deck
+	Entry = deck.Entry
+	Meta  = deck.Meta
+)
 
-type Entry struct {
-	Quantity            int
-	CardName            string
-	Printing            string
-	Foil, Alter, Signed bool
+// ParseDecklist is deck.ParseDecklist, kept for existing callers.
+func ParseDecklist(r io.Reader) (*Deck, error) {
+	return deck.ParseDecklist(r)
+}
 
-	Commander bool
+func init() {
+	deck.Register("tappedout.net", func(ctx context.Context, deckURL string) (*Deck, error) {
+		return DeckFromURLContext(ctx, nil, deckURL)
+	})
 }
 
 var markdownRE = regexp.MustCompile(`\[([^]]*)\]`)
 
 func DeckFromURL(deckURL string) (*Deck, error) {
+	return DeckFromURLContext(context.Background(), nil, deckURL)
+}
+
+// DeckFromURLContext is like DeckFromURL, but uses ctx for all requests
+// to tappedout. If client is nil, http.DefaultClient is used.
+func DeckFromURLContext(ctx context.Context, client *http.Client, deckURL string) (*Deck, error) {
+	return DeckFromURLOptions(ctx, deckURL, &Options{Client: client})
+}
+
+// Options configures requests to tappedout.
+type Options struct {
+	// Client performs requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// Attempts is how many times each request is tried before giving up.
+	// Only connection errors and 5xx responses are retried. If zero,
+	// requests are tried 3 times.
+	Attempts int
+
+	// Backoff is the delay before the first retry, doubled after each
+	// subsequent attempt. If zero, it is one second.
+	Backoff time.Duration
+}
+
+// DeckFromURLOptions is like DeckFromURLContext, with more options. opts
+// may be nil.
+func DeckFromURLOptions(ctx context.Context, deckURL string, opts *Options) (*Deck, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
 	u, err := url.Parse(deckURL)
 	if err != nil {
 		return nil, err
 	}
-	if u.Host != "tappedout.net" && u.Host != "www.tappedout.net" {
+	if deck.Host(u) != "tappedout.net" {
 		return nil, fmt.Errorf("must be a tappedout.net URL; got %q", u.Host)
 	}
 	if !strings.HasPrefix(u.Path, "/mtg-decks/") {
 		return nil, errors.New("must be a deck URL")
 	}
 
-	// NOTE: tappedout is horrible and redirects https to http incorrectly.
-	req, _ := http.NewRequest("GET", fmt.Sprintf("http://tappedout.net%s?fmt=csv", u.Path), nil)
-	req.Header.Set("User-Agent", "github.com_broady_mtg")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := opts.fetch(ctx, u.Path, "csv")
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode > 299 {
-		return nil, fmt.Errorf("%q: non-OK response from tappedout: %s", u.Path, resp.Status)
+
+	d, err := deck.ParseCSV(resp.Body)
+	if err != nil {
+		return nil, err
 	}
 
-	rows, err := csvToMapSlice(resp.Body)
+	resp, err = opts.fetch(ctx, u.Path, "markdown")
 	if err != nil {
 		return nil, err
 	}
-	if len(rows) < 2 {
-		return nil, fmt.Errorf("empty deck")
+	defer resp.Body.Close()
+	sections, err := markdownSections(resp.Body)
+	if err != nil {
+		return nil, err
 	}
-	for _, header := range []string{"Board", "Qty", "Name", "Printing", "Foil", "Alter", "Signed", "Condition", "Languange"} {
-		if rows[0][header] != header {
-			return nil, fmt.Errorf("unknown formatting in tappedout response. missing header %q", header)
+
+	// The CSV's Cmdr column may have found some commanders already.
+	d.Commanders = nil
+	commanders := map[string]bool{}
+	var companion string
+	for heading, names := range sections {
+		for _, name := range names {
+			switch {
+			case strings.Contains(heading, "Commander"):
+				commanders[commanderKey(name)] = true
+			case strings.Contains(heading, "Companion") && companion == "":
+				companion = name
+			}
 		}
 	}
 
-	deck := &Deck{}
-
-	for _, row := range rows[1:] {
-		qty, err := strconv.Atoi(row["Qty"])
-		if err != nil {
-			return nil, fmt.Errorf("bad quantity: %+v", row)
+	for _, entry := range d.Mainboard {
+		if commanders[commanderKey(entry.CardName)] {
+			entry.Commander = true
 		}
-
-		entry := &Entry{
-			Quantity: qty,
-			CardName: row["Name"],
-			Printing: row["Printing"],
-			Foil:     row["Foil"] != "",
-			Alter:    row["Alter"] != "",
-			Signed:   row["Signed"] != "",
+		if entry.Commander {
+			d.Commanders = append(d.Commanders, entry)
 		}
-
-		switch row["Board"] {
-		case "main":
-			deck.Mainboard = append(deck.Mainboard, entry)
-		case "side":
-			deck.Sideboard = append(deck.Sideboard, entry)
-		case "maybe":
-			deck.Maybeboard = append(deck.Maybeboard, entry)
-		case "acquire":
-			deck.Acquireboard = append(deck.Acquireboard, entry)
-		default:
-			return nil, fmt.Errorf("bad board: %+v", row)
+	}
+	if companion != "" {
+		d.Companion = findEntry(companion, d.Sideboard, d.Maybeboard, d.Mainboard)
+		if d.Companion == nil {
+			d.Companion = &Entry{Quantity: 1, CardName: companion}
 		}
 	}
 
-	req, _ = http.NewRequest("GET", fmt.Sprintf("http://tappedout.net%s?fmt=markdown", u.Path), nil)
-	req.Header.Set("User-Agent", "github.com_broady_mtg")
-	resp, err = http.DefaultClient.Do(req)
+	// Meta is best-effort, so the deck is still returned if the page
+	// can't be fetched, unless the caller gave up.
+	meta, err := fetchMeta(ctx, u.Path, opts)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	d.Meta = meta
+	if d.Meta.URL == "" {
+		d.Meta.URL = "http://tappedout.net" + u.Path
+	}
+
+	return d, nil
+}
+
+// fetchMeta fetches the deck page at path and parses its Meta.
+func fetchMeta(ctx context.Context, path string, opts *Options) (Meta, error) {
+	resp, err := opts.fetch(ctx, path, "")
 	if err != nil {
-		return nil, err
+		return Meta{}, err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode > 299 {
-		return nil, fmt.Errorf("%q: non-OK response from tappedout: %s", u.Path, resp.Status)
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Meta{}, err
 	}
-	scanner := bufio.NewScanner(resp.Body)
-	commanders := map[string]bool{}
-outer:
+	return parseMeta(string(b)), nil
+}
+
+// markdownSections reads a deck in tappedout's markdown format, returning
+// the card names listed under each "#" heading.
+func markdownSections(r io.Reader) (map[string][]string, error) {
+	sections := map[string][]string{}
+	var heading string
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		l := scanner.Text()
-		if strings.HasPrefix(l, "#") && strings.Contains(l, "Commander") {
-			for scanner.Scan() {
-				l := scanner.Text()
-				parts := markdownRE.FindStringSubmatch(l)
-				if len(parts) == 2 {
-					commanders[parts[1]] = true
-				}
-				if strings.HasPrefix(l, "#") {
-					// We're in the next section
-					break outer
-				}
-			}
+		if strings.HasPrefix(l, "#") {
+			heading = strings.TrimSpace(strings.TrimLeft(l, "#"))
+			continue
+		}
+		if heading == "" {
+			continue
+		}
+		if parts := markdownRE.FindStringSubmatch(l); len(parts) == 2 {
+			sections[heading] = append(sections[heading], parts[1])
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
+	return sections, scanner.Err()
+}
 
-	for _, entry := range deck.Mainboard {
-		if commanders[entry.CardName] {
-			entry.Commander = true
-			deck.Commanders = append(deck.Commanders, entry)
+// findEntry returns the first entry in boards named name, or nil.
+func findEntry(name string, boards ...[]*Entry) *Entry {
+	for _, board := range boards {
+		for _, e := range board {
+			if commanderKey(e.CardName) == commanderKey(name) {
+				return e
+			}
 		}
 	}
+	return nil
+}
 
-	return deck, nil
+// commanderKey normalizes a card name so the markdown and CSV exports,
+// which differ in case and spacing for some cards, agree.
+func commanderKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
 }
 
-func csvToMapSlice(r io.Reader) ([]map[string]string, error) {
-	b, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, err
+// fetch GETs a page on tappedout.net, in the given format if not empty.
+func (o *Options) fetch(ctx context.Context, path, format string) (*http.Response, error) {
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
 	}
-	rows, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
-	if err != nil {
-		if len(b) > 100 {
-			return nil, fmt.Errorf("csv.Read: %v; \n%s <snip>", err, b[:99])
+	attempts := o.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	backoff := o.Backoff
+	if backoff == 0 {
+		backoff = time.Second
+	}
+
+	// NOTE: tappedout is horrible and redirects https to http incorrectly.
+	u := "http://tappedout.net" + path
+	if format != "" {
+		u += "?fmt=" + format
+	}
+	for i := 1; ; i++ {
+		req, _ := http.NewRequest("GET", u, nil)
+		req = req.WithContext(ctx)
+		req.Header.Set("User-Agent", "github.com_broady_mtg")
+		resp, err := client.Do(req)
+		if err == nil && resp.StatusCode <= 299 {
+			return resp, nil
+		}
+		if err == nil {
+			resp.Body.Close()
+			err = fmt.Errorf("%q: non-OK response from tappedout: %s", path, resp.Status)
+			if resp.StatusCode < 500 {
+				return nil, err
+			}
+		}
+		if i >= attempts || ctx.Err() != nil {
+			return nil, err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
-		return nil, fmt.Errorf("csv.Read: %v; \n%s", err, b)
+		backoff *= 2
 	}
-	if len(rows) == 0 {
-		return nil, nil
+}
+
+var (
+	metaTagRE  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	metaAttrRE = regexp.MustCompile(`(?is)\b(property|name|content)\s*=\s*"([^"]*)"`)
+	titleRE    = regexp.MustCompile(`(?is)<title>(.*?)</title>`)
+	titleFmtRE = regexp.MustCompile(`^(.*?)\s*\((.+?) MTG Deck\)`)
+	byAuthorRE = regexp.MustCompile(`\bby ([\w-]+)`)
+)
+
+// parseMeta scrapes deck metadata from a tappedout deck page. Deck pages
+// are titled like "Deck Name (Commander / EDH MTG Deck)".
+func parseMeta(page string) Meta {
+	tags := map[string]string{}
+	for _, tag := range metaTagRE.FindAllString(page, -1) {
+		var key, content string
+		for _, attr := range metaAttrRE.FindAllStringSubmatch(tag, -1) {
+			if strings.EqualFold(attr[1], "content") {
+				content = html.UnescapeString(attr[2])
+			} else {
+				key = strings.ToLower(attr[2])
+			}
+		}
+		if key != "" {
+			tags[key] = strings.TrimSpace(content)
+		}
 	}
 
-	var resp []map[string]string
-	for _, row := range rows {
-		mapped := map[string]string{}
-		for i, cell := range row {
-			mapped[rows[0][i]] = cell
+	m := Meta{
+		Name:        tags["og:title"],
+		Author:      tags["author"],
+		Description: tags["og:description"],
+		URL:         tags["og:url"],
+	}
+	if m.Description == "" {
+		m.Description = tags["description"]
+	}
+	if t := titleRE.FindStringSubmatch(page); t != nil {
+		title := strings.TrimSpace(html.UnescapeString(t[1]))
+		if parts := titleFmtRE.FindStringSubmatch(title); parts != nil {
+			m.Format = parts[2]
+			if m.Name == "" || titleFmtRE.MatchString(m.Name) {
+				m.Name = parts[1]
+			}
+		} else if m.Name == "" {
+			m.Name = title
+		}
+	}
+	if m.Author == "" {
+		if by := byAuthorRE.FindStringSubmatch(m.Description); by != nil {
+			m.Author = by[1]
 		}
-		resp = append(resp, mapped)
 	}
-	return resp, nil
+	return m
 }
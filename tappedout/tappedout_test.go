@@ -1,6 +1,14 @@
 package tappedout
 
-import "testing"
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
 
 func TestMistform(t *testing.T) {
 	deck, err := DeckFromURL("http://tappedout.net/mtg-decks/04-07-17-mistform-ultimus/")
@@ -43,3 +51,381 @@ func TestNonCommander(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestMeta(t *testing.T) {
+	srv := httptest.NewServer(&stubTappedout{})
+	defer srv.Close()
+
+	deck, err := DeckFromURLOptions(context.Background(), testDeckURL, &Options{Client: stubClient(srv)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Meta{Name: "Krenko", Format: "Commander / EDH", URL: testDeckURL}
+	if deck.Meta != want {
+		t.Errorf("got meta %+v, want %+v", deck.Meta, want)
+	}
+}
+
+func TestParseMeta(t *testing.T) {
+	page := `<html><head>
+<title>Mistform Ultimus (Commander / EDH MTG Deck)</title>
+<meta property="og:title" content="Mistform Ultimus" />
+<meta property="og:url" content="http://tappedout.net/mtg-decks/04-07-17-mistform-ultimus/" />
+<meta property="og:description" content="Commander / EDH deck by broady. Every creature type &amp; more." />
+</head></html>`
+	want := Meta{
+		Name:        "Mistform Ultimus",
+		Author:      "broady",
+		Format:      "Commander / EDH",
+		Description: "Commander / EDH deck by broady. Every creature type & more.",
+		URL:         "http://tappedout.net/mtg-decks/04-07-17-mistform-ultimus/",
+	}
+	if got := parseMeta(page); got != want {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	// Without Open Graph tags, the title still gives the name and format.
+	got := parseMeta(`<title>Jzy Mardu Midrange (Modern MTG Deck)</title><meta name="author" content="jzy">`)
+	want = Meta{Name: "Jzy Mardu Midrange", Author: "jzy", Format: "Modern"}
+	if got != want {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDeckFromURLContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error)
+	go func() {
+		_, err := DeckFromURLContext(ctx, nil, "http://tappedout.net/mtg-decks/04-07-17-mistform-ultimus/")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("got no error with a cancelled context")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("DeckFromURLContext blocked despite a cancelled context")
+	}
+}
+
+// stubTappedout serves a small deck in place of tappedout.net. The first
+// failures requests for each format fail with HTTP 503.
+type stubTappedout struct {
+	failures   int
+	status     int // Status of failed requests; 503 if zero.
+	pageStatus int // If set, the status of every deck page request.
+
+	// onPage, if set, is called while serving each deck page request.
+	onPage func(*http.Request)
+
+	mu       sync.Mutex
+	requests map[string]int
+	csv      string
+	markdown string
+}
+
+const testCSV = `Board,Qty,Name,Printing,Foil,Alter,Signed,Condition,Languange
+main,1,"Krenko, Mob Boss",M13,,,,,
+main,30,Mountain,M20,foil,,,,
+side,2,Pyroblast,ICE,,,,,
+`
+
+const testMarkdown = `# Krenko
+### Commander (1)
+* 1 [Krenko, Mob Boss]
+### Creature (1)
+`
+
+func (s *stubTappedout) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("fmt")
+	s.mu.Lock()
+	if s.requests == nil {
+		s.requests = map[string]int{}
+	}
+	s.requests[format]++
+	n := s.requests[format]
+	s.mu.Unlock()
+
+	if n <= s.failures {
+		status := s.status
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, "try again", status)
+		return
+	}
+	switch format {
+	case "csv":
+		if s.csv != "" {
+			w.Write([]byte(s.csv))
+		} else {
+			w.Write([]byte(testCSV))
+		}
+	case "markdown":
+		if s.markdown != "" {
+			w.Write([]byte(s.markdown))
+		} else {
+			w.Write([]byte(testMarkdown))
+		}
+	default:
+		if s.onPage != nil {
+			s.onPage(r)
+		}
+		if s.pageStatus != 0 {
+			http.Error(w, "oops", s.pageStatus)
+			return
+		}
+		w.Write([]byte(`<title>Krenko (Commander / EDH MTG Deck)</title>`))
+	}
+}
+
+// stubClient returns an http.Client that sends every request to srv.
+func stubClient(srv *httptest.Server) *http.Client {
+	target, _ := url.Parse(srv.URL)
+	return &http.Client{Transport: rewriteTransport{target}}
+}
+
+type rewriteTransport struct{ target *url.URL }
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+const testDeckURL = "http://tappedout.net/mtg-decks/krenko/"
+
+func TestRetry(t *testing.T) {
+	stub := &stubTappedout{failures: 2}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	opts := &Options{Client: stubClient(srv), Backoff: time.Millisecond}
+	deck, err := DeckFromURLOptions(context.Background(), testDeckURL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deck.Mainboard) != 2 || len(deck.Commanders) != 1 {
+		t.Errorf("got deck %+v, want 2 mainboard entries and a commander", deck)
+	}
+	if got := stub.requests["csv"]; got != 3 {
+		t.Errorf("got %d CSV requests, want 3", got)
+	}
+
+	stub = &stubTappedout{failures: 2}
+	srv2 := httptest.NewServer(stub)
+	defer srv2.Close()
+	opts = &Options{Client: stubClient(srv2), Attempts: 1}
+	if _, err := DeckFromURLOptions(context.Background(), testDeckURL, opts); err == nil {
+		t.Error("got no error with retries disabled")
+	}
+	if got := stub.requests["csv"]; got != 1 {
+		t.Errorf("got %d CSV requests with retries disabled, want 1", got)
+	}
+}
+
+func TestMetaPageFails(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusInternalServerError} {
+		stub := &stubTappedout{pageStatus: status}
+		srv := httptest.NewServer(stub)
+		defer srv.Close()
+
+		opts := &Options{Client: stubClient(srv), Backoff: time.Millisecond}
+		deck, err := DeckFromURLOptions(context.Background(), testDeckURL, opts)
+		if err != nil {
+			t.Errorf("page status %d: %v", status, err)
+			continue
+		}
+		if len(deck.Mainboard) != 2 || len(deck.Commanders) != 1 {
+			t.Errorf("page status %d: got deck %+v, want 2 mainboard entries and a commander", status, deck)
+		}
+		if want := (Meta{URL: "http://tappedout.net/mtg-decks/krenko/"}); deck.Meta != want {
+			t.Errorf("page status %d: got meta %+v, want %+v", status, deck.Meta, want)
+		}
+	}
+}
+
+func TestMixedCaseHost(t *testing.T) {
+	srv := httptest.NewServer(&stubTappedout{})
+	defer srv.Close()
+
+	deck, err := DeckFromURLOptions(context.Background(), "https://TappedOut.net:443/mtg-decks/krenko/", &Options{Client: stubClient(srv)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deck.Mainboard) != 2 {
+		t.Errorf("got deck %+v, want 2 mainboard entries", deck)
+	}
+}
+
+func TestMetaCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stub := &stubTappedout{onPage: func(r *http.Request) {
+		// Give up while the page, the last request, is in flight.
+		cancel()
+		<-r.Context().Done()
+	}}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	deck, err := DeckFromURLOptions(ctx, testDeckURL, &Options{Client: stubClient(srv)})
+	if err != context.Canceled {
+		t.Errorf("got deck %+v and error %v, want %v", deck, err, context.Canceled)
+	}
+}
+
+func TestNoRetryOn4xx(t *testing.T) {
+	stub := &stubTappedout{failures: 1, status: http.StatusNotFound}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	opts := &Options{Client: stubClient(srv), Backoff: time.Millisecond}
+	if _, err := DeckFromURLOptions(context.Background(), testDeckURL, opts); err == nil {
+		t.Error("got no error for a 404")
+	}
+	if got := stub.requests["csv"]; got != 1 {
+		t.Errorf("got %d CSV requests, want 1", got)
+	}
+}
+
+func TestCommanderFromCSV(t *testing.T) {
+	stub := &stubTappedout{
+		csv: `Board,Qty,Name,Printing,Foil,Alter,Signed,Condition,Languange,Cmdr
+main,1,"Krenko, Mob Boss",M13,,,,,,True
+main,30,Mountain,M20,foil,,,,,
+`,
+		markdown: "# Krenko\n### Creature (1)\n* 1 [Krenko, Mob Boss]\n",
+	}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	deck, err := DeckFromURLOptions(context.Background(), testDeckURL, &Options{Client: stubClient(srv)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deck.Commanders) != 1 || deck.Commanders[0].CardName != "Krenko, Mob Boss" {
+		t.Fatalf("got commanders %+v, want Krenko", deck.Commanders)
+	}
+	if !deck.Commanders[0].Commander || deck.Mainboard[1].Commander {
+		t.Errorf("got Commander flags %v and %v, want true and false", deck.Commanders[0].Commander, deck.Mainboard[1].Commander)
+	}
+}
+
+func TestCommanderNameMismatch(t *testing.T) {
+	stub := &stubTappedout{markdown: "### Commander (1)\n* 1 [krenko,  mob boss]\n"}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	deck, err := DeckFromURLOptions(context.Background(), testDeckURL, &Options{Client: stubClient(srv)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deck.Commanders) != 1 || deck.Commanders[0].CardName != "Krenko, Mob Boss" {
+		t.Errorf("got commanders %+v, want Krenko", deck.Commanders)
+	}
+}
+
+func TestCompanion(t *testing.T) {
+	stub := &stubTappedout{
+		csv: testCSV + "side,1,Lurrus of the Dream-Den,IKO,,,,,\n",
+		markdown: testMarkdown + `### Companion (1)
+* 1 [Lurrus of the Dream-Den]
+### Sideboard (2)
+* 2 [Pyroblast]
+`,
+	}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	deck, err := DeckFromURLOptions(context.Background(), testDeckURL, &Options{Client: stubClient(srv)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deck.Companion == nil || deck.Companion != deck.Sideboard[1] {
+		t.Errorf("got companion %+v, want the Lurrus sideboard entry", deck.Companion)
+	}
+	if len(deck.Commanders) != 1 {
+		t.Errorf("got %d commanders, want 1", len(deck.Commanders))
+	}
+
+	stub = &stubTappedout{}
+	srv2 := httptest.NewServer(stub)
+	defer srv2.Close()
+	deck, err = DeckFromURLOptions(context.Background(), testDeckURL, &Options{Client: stubClient(srv2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deck.Companion != nil {
+		t.Errorf("got companion %+v, want nil", deck.Companion)
+	}
+}
+
+func TestConditionAndLanguage(t *testing.T) {
+	stub := &stubTappedout{csv: `Board,Qty,Name,Printing,Foil,Alter,Signed,Condition,Languange
+main,1,"Krenko, Mob Boss",M13,,,,LP,JA
+main,30,Mountain,M20,foil,,,,
+`}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	deck, err := DeckFromURLOptions(context.Background(), testDeckURL, &Options{Client: stubClient(srv)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := deck.Mainboard[0]; e.Condition != "LP" || e.Language != "JA" {
+		t.Errorf("got condition %q and language %q, want LP and JA", e.Condition, e.Language)
+	}
+	if e := deck.Mainboard[1]; e.Condition != "" || e.Language != "" {
+		t.Errorf("got condition %q and language %q, want them unspecified", e.Condition, e.Language)
+	}
+}
+
+func TestCSVHeaders(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		csv  string
+	}{
+		{"extra column", `Board,Qty,Name,Printing,Foil,Alter,Signed,Condition,Languange,Price
+main,1,"Krenko, Mob Boss",M13,,,,,,0.99
+`},
+		{"reordered", `Name,Board,Qty,Printing
+"Krenko, Mob Boss",main,1,M13
+`},
+	} {
+		srv := httptest.NewServer(&stubTappedout{csv: c.csv})
+		deck, err := DeckFromURLOptions(context.Background(), testDeckURL, &Options{Client: stubClient(srv)})
+		srv.Close()
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if len(deck.Mainboard) != 1 || deck.Mainboard[0].CardName != "Krenko, Mob Boss" || deck.Mainboard[0].Printing != "M13" {
+			t.Errorf("%s: got mainboard %+v", c.name, deck.Mainboard)
+		}
+	}
+
+	srv := httptest.NewServer(&stubTappedout{csv: "Board,Name\nmain,Mountain\n"})
+	defer srv.Close()
+	if _, err := DeckFromURLOptions(context.Background(), testDeckURL, &Options{Client: stubClient(srv)}); err == nil {
+		t.Error("got no error for a CSV without a Qty column")
+	}
+}
+
+func TestLanguageHeader(t *testing.T) {
+	for _, header := range []string{"Languange", "Language", "language"} {
+		srv := httptest.NewServer(&stubTappedout{csv: "board,QTY,Name,Condition," + header + "\nmain,30,Mountain,NM,EN\n"})
+		deck, err := DeckFromURLOptions(context.Background(), testDeckURL, &Options{Client: stubClient(srv)})
+		srv.Close()
+		if err != nil {
+			t.Errorf("%s: %v", header, err)
+			continue
+		}
+		if e := deck.Mainboard[0]; e.Quantity != 30 || e.Language != "EN" || e.Condition != "NM" {
+			t.Errorf("%s: got entry %+v", header, e)
+		}
+	}
+}
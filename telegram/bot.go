@@ -1,26 +1,38 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
-	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/broady/conf"
+	"github.com/broady/mtg/analysis"
+	_ "github.com/broady/mtg/archidekt"
 	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+	_ "github.com/broady/mtg/moxfield"
+	_ "github.com/broady/mtg/tappedout"
 	"github.com/davecgh/go-spew/spew"
 	tg "github.com/go-telegram-bot-api/telegram-bot-api"
 )
 
-var verbose = flag.Bool("v", false, "verbose")
+var (
+	verbose   = flag.Bool("v", false, "verbose")
+	plainMana = flag.Bool("plain-mana", false, "show mana costs as text, like {2}{U}, instead of emoji")
+)
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
@@ -30,17 +42,26 @@ func main() {
 	b, err := tg.NewBotAPI(tok)
 	fatal(err)
 
-	bot := &mtgBot{b: b, store: cards.NewStore()}
+	// MTG_DEFAULT_FORMAT, such as "modern", scopes inline searches that
+	// don't name a format.
+	bot := &mtgBot{b: b, store: cards.NewStore(), defaultFormat: os.Getenv("MTG_DEFAULT_FORMAT")}
 	fatal(bot.Start())
 }
 
 type mtgBot struct {
 	b     *tg.BotAPI
 	store *cards.Store
+	cache *resultCache
+
+	defaultFormat string // Added to inline queries without a format. Optional.
 }
 
 func (bot *mtgBot) Start() error {
 	bot.store = cards.NewStore()
+	bot.cache = newResultCache(1000)
+	go bot.cache.clearOnUpdate(func() <-chan *cards.Cards {
+		return bot.store.WaitForUpdateContext(context.Background())
+	})
 
 	updates, err := bot.b.GetUpdatesChan(tg.UpdateConfig{Timeout: 60})
 	if err != nil {
@@ -55,6 +76,8 @@ func (bot *mtgBot) Start() error {
 		switch {
 		case u.InlineQuery != nil:
 			go bot.handleInline(u.UpdateID, u.InlineQuery)
+		case u.Message != nil:
+			go bot.handleMessage(u.Message)
 		default:
 			vlog("unhandled")
 			vlog(u)
@@ -72,36 +95,296 @@ func (bot *mtgBot) handleInline(id int, q *tg.InlineQuery) {
 		return
 	}
 
-	cards, err := bot.store.Cards().Query(q.Query)
-	if err != nil {
+	if name := strings.TrimPrefix(q.Query, "rulings "); name != q.Query {
+		bot.handleRulings(reply, name)
+		return
+	}
+
+	// The default format is fixed, so the raw query is a fine cache key.
+	reply.Results, reply.NextOffset = bot.cache.get(q.Query, q.Offset, func() ([]interface{}, string) {
+		return queryResults(bot.store.Cards(), q.Query, q.Offset, bot.defaultFormat)
+	})
+	if _, err := bot.b.AnswerInlineQuery(reply); err != nil {
 		vlog(err)
+	}
+}
+
+// withDefaultFormat restricts q to cards legal in format, as an "f:" term
+// would, unless format is empty or q already has an f:, legal: or banned:
+// term, including in OR alternatives. The format applies to all of q,
+// not to one of its alternatives.
+func withDefaultFormat(q *cards.Query, format string) {
+	if format != "" && !hasFormat(q) {
+		q.Format = append(q.Format, strings.ToLower(format))
+	}
+}
+
+func hasFormat(q *cards.Query) bool {
+	if len(q.Format) > 0 || len(q.Banned) > 0 || len(q.Legal) > 0 {
+		return true
+	}
+	for _, group := range q.Or {
+		for _, alt := range group {
+			if hasFormat(alt) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inlinePageSize is the number of cards in each page of inline results.
+const inlinePageSize = 10
+
+// queryResults returns a page of inline results for a card query,
+// starting at offset, and the offset of the next page, or "" if it is the
+// last. Queries without a format are restricted to defaultFormat, if
+// set. If there are no matches, it returns a single article explaining
+// why.
+func queryResults(corpus *cards.Cards, query, offset, defaultFormat string) ([]interface{}, string) {
+	q, err := cards.ParseQueryStrict(query)
+	if err != nil {
+		return []interface{}{textResult("error", "Bad query", err.Error())}, ""
+	}
+	withDefaultFormat(q, defaultFormat)
+	start, _ := strconv.Atoi(offset)
+	page, total := corpus.SearchPage(q, start, inlinePageSize)
+	if total == 0 {
+		return []interface{}{textResult("none", "No cards matched", fmt.Sprintf("No cards matched `%s`", query))}, ""
+	}
+
+	var results []interface{}
+	for _, c := range page {
+		results = append(results, inlineResult(c))
+	}
+	next := ""
+	if end := start + len(page); len(page) > 0 && end < total {
+		next = strconv.Itoa(end)
+	}
+	return results, next
+}
+
+// textResult returns an article that sends text when chosen.
+func textResult(id, title, text string) tg.InlineQueryResultArticle {
+	res := tg.NewInlineQueryResultArticle(id, title, text)
+	res.Description = text
+	res.InputMessageContent = tg.InputTextMessageContent{Text: text}
+	return res
+}
+
+// inlineResult returns an inline query result showing the card's image,
+// or a text description if it has none; see cards.Card.HasImage.
+func inlineResult(c *cards.Card) interface{} {
+	title := fmt.Sprintf("%s %v", c.Name, c.Types)
+	description := c.Text
+	const maxDescription = 100
+	if len(description) > maxDescription {
+		description = description[:maxDescription-3] + "..."
+	}
+
+	if c.HasImage() {
+		img := c.ImageURL()
+		res := tg.NewInlineQueryResultPhotoWithThumb(c.Name, img, img)
+		res.Title = title
+		res.Description = description
+		res.Caption = caption(c)
+		return res
+	}
+
+	res := tg.NewInlineQueryResultArticle(c.Name, title, "")
+	res.Description = description
+	res.InputMessageContent = tg.InputTextMessageContent{
+		Text:      fmt.Sprintf("*%s* %s\n%s", c.Name, manaCost(c), c.Text),
+		ParseMode: tg.ModeMarkdown,
+	}
+	return res
+}
+
+// caption returns a short description of a card to go with its image.
+func caption(c *cards.Card) string {
+	return fmt.Sprintf("%s %s", c.Name, manaCost(c))
+}
+
+// manaCost returns the card's mana cost for display.
+func manaCost(c *cards.Card) string {
+	if *plainMana {
+		return c.ManaCost
+	}
+	return cards.FormatManaCost(c.ManaCost)
+}
+
+const usage = `Send a card query inline, e.g. "@bot c:r t:goblin", or:
+/card <name> - show a card
+/random [query] - show a random card, optionally matching a query
+/help - show this message`
+
+// handleMessage answers commands sent to the bot in a chat.
+func (bot *mtgBot) handleMessage(m *tg.Message) {
+	if m.Chat == nil {
 		return
 	}
+	card, text := commandReply(bot.store.Cards(), m.Text, bot.b.Self.UserName)
+	if card == nil && text == "" {
+		if u := findDeckURL(m.Text); u != "" {
+			text = bot.deckReply(u)
+		}
+	}
+	var msg tg.Chattable
+	switch {
+	case card != nil && card.HasImage():
+		photo := tg.NewPhotoShare(m.Chat.ID, card.ImageURL())
+		photo.Caption = caption(card)
+		photo.ReplyToMessageID = m.MessageID
+		msg = photo
+	case card != nil:
+		text = fmt.Sprintf("%s\n%s", caption(card), card.Text)
+		fallthrough
+	case text != "":
+		reply := tg.NewMessage(m.Chat.ID, text)
+		reply.ReplyToMessageID = m.MessageID
+		msg = reply
+	default:
+		return
+	}
+	if _, err := bot.b.Send(msg); err != nil {
+		vlog(err)
+	}
+}
 
-	for _, c := range cards {
-		if len(reply.Results) > 10 {
-			break
+// commandReply returns the answer to a message: a card to show, text to
+// send, or neither if the message isn't a command for this bot. In
+// groups, commands may be addressed to a bot as "/card@botname".
+func commandReply(corpus *cards.Cards, text, botName string) (*cards.Card, string) {
+	if !strings.HasPrefix(text, "/") {
+		return nil, ""
+	}
+	cmd, args := text[1:], ""
+	if i := strings.IndexAny(cmd, " \n"); i >= 0 {
+		cmd, args = cmd[:i], strings.TrimSpace(cmd[i+1:])
+	}
+	if i := strings.Index(cmd, "@"); i >= 0 {
+		if !strings.EqualFold(cmd[i+1:], botName) {
+			return nil, ""
 		}
+		cmd = cmd[:i]
+	}
 
-		title := fmt.Sprintf("%s %v", c.Name, c.Types)
-		txt := fmt.Sprintf(`*%s* %s
-%s
-https://api.scryfall.com/cards/named/?exact=%s&format=image`,
-			c.Name, c.ManaCost,
-			c.Text, url.QueryEscape(c.Name))
-
-		res := tg.NewInlineQueryResultArticle(c.Name, title, "")
-		res.Description = c.Text
-		const maxDescription = 100
-		if len(res.Description) > maxDescription {
-			res.Description = res.Description[:maxDescription-3] + "..."
+	switch strings.ToLower(cmd) {
+	case "card":
+		if args == "" {
+			return nil, "Usage: /card <name>"
 		}
-		res.InputMessageContent = tg.InputTextMessageContent{
-			Text:      txt,
-			ParseMode: tg.ModeMarkdown,
+		if c := lookup(corpus, args); c != nil {
+			return c, ""
 		}
+		return nil, fmt.Sprintf("No card named %q", args)
+	case "random":
+		if c := randomCard(corpus, args); c != nil {
+			return c, ""
+		}
+		return nil, fmt.Sprintf("No cards matched `%s`", args)
+	case "help", "start":
+		return nil, usage
+	}
+	return nil, ""
+}
+
+// randomCard returns a card chosen uniformly at random from those
+// matching query, or from all cards if query is empty. It returns nil if
+// none match.
+func randomCard(corpus *cards.Cards, query string) *cards.Card {
+	match := corpus.Search(cards.ParseQuery(query))
+	if len(match) == 0 {
+		return nil
+	}
+	return match[rand.Intn(len(match))]
+}
+
+// maxFuzzyDistance is how many typos lookup forgives.
+const maxFuzzyDistance = 3
 
-		reply.Results = append(reply.Results, res)
+// lookup finds a card by name, forgiving case, punctuation and small
+// typos.
+func lookup(corpus *cards.Cards, name string) *cards.Card {
+	if c := corpus.LookupNormalized(name); c != nil {
+		return c
+	}
+	if c, dist := corpus.LookupFuzzy(name); dist >= 0 && dist <= maxFuzzyDistance {
+		return c
+	}
+	return nil
+}
+
+// deckURLRE matches links to decks on the hosts with registered importers.
+var deckURLRE = regexp.MustCompile(`https?://(?:www\.)?(?:tappedout\.net|moxfield\.com|archidekt\.com)/\S+`)
+
+// findDeckURL returns the first deck link in text, or "".
+func findDeckURL(text string) string {
+	return deckURLRE.FindString(text)
+}
+
+// deckReply fetches the deck at deckURL and returns its summary, or a
+// message saying why it couldn't be fetched.
+func (bot *mtgBot) deckReply(deckURL string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	d, err := deck.FromURL(ctx, deckURL)
+	if err != nil {
+		vlog(err)
+		return "Sorry, I couldn't load that deck. Is it public?"
+	}
+	return deckSummary(d, bot.store.Cards())
+}
+
+// deckSummary describes a deck: its name and author, commanders, card
+// count and color identity.
+func deckSummary(d *deck.Deck, corpus *cards.Cards) string {
+	var buf bytes.Buffer
+	name := d.Meta.Name
+	if name == "" {
+		name = "Untitled deck"
+	}
+	buf.WriteString(name)
+	if d.Meta.Author != "" {
+		fmt.Fprintf(&buf, " by %s", d.Meta.Author)
+	}
+	buf.WriteString("\n")
+
+	if len(d.Commanders) > 0 {
+		var names []string
+		for _, e := range d.Commanders {
+			names = append(names, e.CardName)
+		}
+		fmt.Fprintf(&buf, "Commander: %s\n", strings.Join(names, " & "))
+	}
+	fmt.Fprintf(&buf, "%d cards", d.MainboardCount())
+	if n := d.SideboardCount(); n > 0 {
+		fmt.Fprintf(&buf, ", %d in the sideboard", n)
+	}
+	if len(d.Commanders) > 0 {
+		identity := strings.Join(analysis.ColorIdentity(d, corpus), "")
+		if identity == "" {
+			identity = "colorless"
+		}
+		fmt.Fprintf(&buf, "\nColor identity: %s", identity)
+	}
+	return buf.String()
+}
+
+// handleRulings answers an inline query of the form "rulings <card>" with
+// the card's most recent rulings.
+func (bot *mtgBot) handleRulings(reply tg.InlineConfig, name string) {
+	c := bot.store.Cards().LookupNormalized(name)
+	if c != nil {
+		for i, r := range c.LatestRulings(10) {
+			res := tg.NewInlineQueryResultArticle(fmt.Sprintf("%s/ruling/%d", c.Name, i), fmt.Sprintf("%s (%s)", c.Name, r.Date), "")
+			res.Description = r.Text
+			res.InputMessageContent = tg.InputTextMessageContent{
+				Text:      fmt.Sprintf("*%s* ruling (%s):\n%s", c.Name, r.Date, r.Text),
+				ParseMode: tg.ModeMarkdown,
+			}
+			reply.Results = append(reply.Results, res)
+		}
 	}
 
 	if _, err := bot.b.AnswerInlineQuery(reply); err != nil {
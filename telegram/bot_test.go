@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/broady/mtg/cards"
+	"github.com/broady/mtg/deck"
+	tg "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+func TestInlineResult(t *testing.T) {
+	c := &cards.Card{Name: "Fire // Ice", ManaCost: "{1}{R} // {1}{U}", Types: []string{"Instant"}, Text: "Fire deals 2 damage divided as you choose among one or two targets.", ScryfallOracleID: "fire-ice"}
+	res, ok := inlineResult(c).(tg.InlineQueryResultPhoto)
+	if !ok {
+		t.Fatalf("got %T, want a photo result", inlineResult(c))
+	}
+	for _, u := range []string{res.URL, res.ThumbURL} {
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Scheme != "https" || parsed.Query().Get("exact") != c.Name {
+			t.Errorf("got image URL %q, want an https URL for %q", u, c.Name)
+		}
+	}
+	if res.ID != c.Name || res.Title == "" {
+		t.Errorf("got ID %q and title %q", res.ID, res.Title)
+	}
+
+	// Without a scryfall ID or a Gatherer printing, there's no image.
+	c.ScryfallOracleID = ""
+	article, ok := inlineResult(c).(tg.InlineQueryResultArticle)
+	if !ok {
+		t.Fatalf("got %T for a card without an image, want an article", inlineResult(c))
+	}
+	content, _ := article.InputMessageContent.(tg.InputTextMessageContent)
+	if article.ID != c.Name || !strings.Contains(content.Text, c.Name) || !strings.Contains(content.Text, c.Text) {
+		t.Errorf("got article %q with text %q, want the card's name and text", article.ID, content.Text)
+	}
+}
+
+func TestWithDefaultFormat(t *testing.T) {
+	for _, c := range []struct {
+		query, format string
+		want          []string
+	}{
+		{"goblin", "modern", []string{"modern"}},
+		{"t:goblin c:r", "Modern", []string{"modern"}},
+		{"f:legacy goblin", "modern", []string{"legacy"}},
+		{"legal:pauper,modern goblin", "modern", nil},
+		{"banned:modern", "modern", nil},
+		{"t:goblin f:legacy OR f:vintage", "modern", nil},
+		{"goblin", "", nil},
+		// The format isn't pulled into an unterminated quote or an OR.
+		{`o:"draw`, "modern", []string{"modern"}},
+		{"c:r OR", "modern", []string{"modern"}},
+		{"c:r OR c:u t:goblin", "modern", []string{"modern"}},
+	} {
+		q, want := cards.ParseQuery(c.query), cards.ParseQuery(c.query)
+		want.Format = c.want
+		withDefaultFormat(q, c.format)
+		if !reflect.DeepEqual(q, want) {
+			t.Errorf("%q with %q: got %+v, want %+v", c.query, c.format, q, want)
+		}
+	}
+}
+
+func TestQueryResultsDefaultFormat(t *testing.T) {
+	legal := func(format string) []cards.FormatLegality {
+		return []cards.FormatLegality{{Format: format, Legality: "Legal"}}
+	}
+	corpus := cards.NewCards(map[string]*cards.Card{
+		"Goblin Guide":       {Name: "Goblin Guide", Types: []string{"Creature"}, Legalities: legal("modern"), ScryfallOracleID: "guide"},
+		"Goblin Lackey":      {Name: "Goblin Lackey", Types: []string{"Creature"}, Legalities: legal("legacy"), ScryfallOracleID: "lackey"},
+		"Goblin Bushwhacker": {Name: "Goblin Bushwhacker", Types: []string{"Creature"}, Legalities: legal("modern"), ScryfallOracleID: "bushwhacker"},
+	})
+	for _, c := range []struct {
+		query, format string
+		want          []string
+	}{
+		{"goblin", "", []string{"Goblin Bushwhacker", "Goblin Guide", "Goblin Lackey"}},
+		{"goblin", "modern", []string{"Goblin Bushwhacker", "Goblin Guide"}},
+		{"goblin f:legacy", "modern", []string{"Goblin Lackey"}},
+		{"guide OR lackey", "modern", []string{"Goblin Guide"}},
+	} {
+		results, _ := queryResults(corpus, c.query, "", c.format)
+		var got []string
+		for _, r := range results {
+			if photo, ok := r.(tg.InlineQueryResultPhoto); ok {
+				got = append(got, photo.ID)
+			}
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q with default format %q: got %q, want %q", c.query, c.format, got, c.want)
+		}
+	}
+}
+
+func TestQueryResults(t *testing.T) {
+	corpus := cards.NewCards(map[string]*cards.Card{
+		"Shock": {Name: "Shock", Type: "Instant", Types: []string{"Instant"}, Colors: []string{"Red"}, ScryfallOracleID: "shock"},
+	})
+
+	results, _ := queryResults(corpus, "t:instant", "", "")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if _, ok := results[0].(tg.InlineQueryResultPhoto); !ok {
+		t.Errorf("got %T, want a photo result", results[0])
+	}
+
+	for _, c := range []struct {
+		query, id string
+	}{
+		{"t:creature", "none"},
+		{"cmc>=x", "error"},
+	} {
+		results, _ := queryResults(corpus, c.query, "", "")
+		if len(results) != 1 {
+			t.Errorf("%q: got %d results, want 1", c.query, len(results))
+			continue
+		}
+		res, ok := results[0].(tg.InlineQueryResultArticle)
+		if !ok || res.ID != c.id {
+			t.Errorf("%q: got %+v, want a %q article", c.query, results[0], c.id)
+		}
+	}
+}
+
+func TestQueryResultsPagination(t *testing.T) {
+	m := map[string]*cards.Card{}
+	for i := 0; i < 25; i++ {
+		name := fmt.Sprintf("Goblin %02d", i)
+		m[name] = &cards.Card{Name: name, Type: "Creature — Goblin", ScryfallOracleID: name}
+	}
+	corpus := cards.NewCards(m)
+
+	var got []string
+	offset := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("too many pages")
+		}
+		results, next := queryResults(corpus, "t:goblin", offset, "")
+		for _, r := range results {
+			got = append(got, r.(tg.InlineQueryResultPhoto).ID)
+		}
+		if next == "" {
+			break
+		}
+		if want := strconv.Itoa(len(got)); next != want {
+			t.Fatalf("got next offset %q, want %q", next, want)
+		}
+		offset = next
+	}
+	if len(got) != 25 {
+		t.Fatalf("got %d results, want 25", len(got))
+	}
+	for i, id := range got {
+		if want := fmt.Sprintf("Goblin %02d", i); id != want {
+			t.Errorf("result %d: got %q, want %q", i, id, want)
+		}
+	}
+}
+
+func TestCommandReply(t *testing.T) {
+	corpus := cards.NewCards(map[string]*cards.Card{
+		"Shock": {Name: "Shock", ManaCost: "{R}"},
+	})
+	for _, c := range []struct {
+		text     string
+		card     string
+		wantText bool
+	}{
+		{"/card Shock", "Shock", false},
+		{"/card shokc", "Shock", false},
+		{"/card@mtgbot Shock", "Shock", false},
+		{"/card@otherbot Shock", "", false},
+		{"/card Lightning Bolt", "", true},
+		{"/card", "", true},
+		{"/help", "", true},
+		{"/help@MTGBot", "", true},
+		{"/unknown", "", false},
+		{"Shock", "", false},
+	} {
+		card, text := commandReply(corpus, c.text, "mtgbot")
+		var got string
+		if card != nil {
+			got = card.Name
+		}
+		if got != c.card || (text != "") != c.wantText {
+			t.Errorf("commandReply(%q) = %q, %q; want card %q, text %v", c.text, got, text, c.card, c.wantText)
+		}
+	}
+	if _, text := commandReply(corpus, "/help", "mtgbot"); text != usage {
+		t.Errorf("got help %q, want usage", text)
+	}
+}
+
+func TestRandomCard(t *testing.T) {
+	corpus := cards.NewCards(map[string]*cards.Card{
+		"Shock":          {Name: "Shock", Type: "Instant", Colors: []string{"Red"}},
+		"Goblin Guide":   {Name: "Goblin Guide", Type: "Creature — Goblin Scout", Colors: []string{"Red"}},
+		"Llanowar Elves": {Name: "Llanowar Elves", Type: "Creature — Elf Druid", Colors: []string{"Green"}},
+	})
+	for i := 0; i < 20; i++ {
+		if c := randomCard(corpus, "c:r t:creature"); c == nil || c.Name != "Goblin Guide" {
+			t.Fatalf("got %+v, want Goblin Guide", c)
+		}
+		if c := randomCard(corpus, ""); c == nil {
+			t.Fatal("got no card for an empty query")
+		}
+	}
+	if c := randomCard(corpus, "t:planeswalker"); c != nil {
+		t.Errorf("got %+v, want nil", c)
+	}
+	if c := randomCard(cards.NewCards(map[string]*cards.Card{}), ""); c != nil {
+		t.Errorf("got %+v from an empty corpus, want nil", c)
+	}
+}
+
+func TestFindDeckURL(t *testing.T) {
+	for _, c := range []struct {
+		text, want string
+	}{
+		{"check out http://tappedout.net/mtg-decks/krenko/ please", "http://tappedout.net/mtg-decks/krenko/"},
+		{"https://www.moxfield.com/decks/abc123", "https://www.moxfield.com/decks/abc123"},
+		{"https://archidekt.com/decks/1234/krenko!", "https://archidekt.com/decks/1234/krenko!"},
+		{"https://example.com/decks/1", ""},
+		{"/card Shock", ""},
+	} {
+		if got := findDeckURL(c.text); got != c.want {
+			t.Errorf("findDeckURL(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestDeckSummary(t *testing.T) {
+	corpus := cards.NewCards(map[string]*cards.Card{
+		"Krenko, Mob Boss": {Name: "Krenko, Mob Boss", ColorIdentity: []string{"R"}},
+	})
+	commander := &deck.Entry{Quantity: 1, CardName: "Krenko, Mob Boss", Commander: true}
+	d := &deck.Deck{
+		Meta:       deck.Meta{Name: "Krenko Tokens", Author: "goblinking"},
+		Mainboard:  []*deck.Entry{commander, {Quantity: 99, CardName: "Mountain"}},
+		Commanders: []*deck.Entry{commander},
+	}
+	want := "Krenko Tokens by goblinking\nCommander: Krenko, Mob Boss\n100 cards\nColor identity: R"
+	if got := deckSummary(d, corpus); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	d = &deck.Deck{
+		Mainboard: []*deck.Entry{{Quantity: 60, CardName: "Mountain"}},
+		Sideboard: []*deck.Entry{{Quantity: 15, CardName: "Pyroblast"}},
+	}
+	if got, want := deckSummary(d, corpus), "Untitled deck\n60 cards, 15 in the sideboard"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
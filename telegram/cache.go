@@ -0,0 +1,86 @@
+package main
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"github.com/broady/mtg/cards"
+)
+
+// resultCache is an LRU cache of inline query results. It is safe for
+// concurrent use.
+type resultCache struct {
+	max int
+
+	mu    sync.Mutex
+	ll    *list.List // Most recently used first.
+	items map[string]*list.Element
+	gen   int // Incremented by clear, so stale results aren't cached.
+}
+
+type cacheEntry struct {
+	key     string
+	results []interface{}
+	next    string
+}
+
+func newResultCache(max int) *resultCache {
+	return &resultCache{max: max, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+// cacheKey normalizes the whitespace in query, so that results aren't
+// recomputed as the user types spaces.
+func cacheKey(query, offset string) string {
+	return strings.Join(strings.Fields(query), " ") + "\x00" + offset
+}
+
+// get returns the results for query and offset, calling compute and
+// caching its results if they aren't already cached.
+func (c *resultCache) get(query, offset string, compute func() ([]interface{}, string)) ([]interface{}, string) {
+	key := cacheKey(query, offset)
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		e := el.Value.(*cacheEntry)
+		c.mu.Unlock()
+		return e.results, e.next
+	}
+	gen := c.gen
+	c.mu.Unlock()
+
+	results, next := compute()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; !ok && gen == c.gen {
+		c.items[key] = c.ll.PushFront(&cacheEntry{key: key, results: results, next: next})
+		if c.ll.Len() > c.max {
+			oldest := c.ll.Back()
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*cacheEntry).key)
+		}
+	}
+	return results, next
+}
+
+// clear empties the cache.
+func (c *resultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = map[string]*list.Element{}
+	c.gen++
+}
+
+// clearOnUpdate clears the cache each time the corpus is updated, as
+// reported by a channel from wait, such as Store.WaitForUpdateContext. It
+// returns when a channel is closed without an update.
+func (c *resultCache) clearOnUpdate(wait func() <-chan *cards.Cards) {
+	for {
+		if _, ok := <-wait(); !ok {
+			return
+		}
+		c.clear()
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/broady/mtg/cards"
+)
+
+func TestResultCache(t *testing.T) {
+	c := newResultCache(2)
+	calls := 0
+	compute := func() ([]interface{}, string) {
+		calls++
+		return []interface{}{calls}, "10"
+	}
+
+	c.get("t:goblin", "", compute)
+	results, next := c.get("t:goblin  ", "", compute)
+	if calls != 1 || results[0] != 1 || next != "10" {
+		t.Errorf("got %v, %q after %d computations; want a cache hit", results, next, calls)
+	}
+	c.get("t:goblin", "10", compute)
+	if calls != 2 {
+		t.Errorf("got %d computations, want a miss for a new offset", calls)
+	}
+
+	// Evicts the least recently used entry, t:goblin at offset 10.
+	c.get("t:goblin", "", compute)
+	c.get("c:r", "", compute)
+	c.get("t:goblin", "10", compute)
+	if calls != 4 {
+		t.Errorf("got %d computations, want 4", calls)
+	}
+	if c.ll.Len() != 2 || len(c.items) != 2 {
+		t.Errorf("got %d entries, want at most 2", c.ll.Len())
+	}
+}
+
+func TestResultCacheClearOnUpdate(t *testing.T) {
+	c := newResultCache(10)
+	calls := 0
+	compute := func() ([]interface{}, string) {
+		calls++
+		return nil, ""
+	}
+	c.get("t:goblin", "", compute)
+
+	updates := make(chan chan *cards.Cards)
+	done := make(chan bool)
+	go func() {
+		c.clearOnUpdate(func() <-chan *cards.Cards { return <-updates })
+		close(done)
+	}()
+
+	update := make(chan *cards.Cards, 1)
+	update <- cards.NewCards(nil)
+	updates <- update
+	closed := make(chan *cards.Cards)
+	close(closed)
+	updates <- closed // Sent after the first update is handled.
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("clearOnUpdate didn't return")
+	}
+
+	c.get("t:goblin", "", compute)
+	if calls != 2 {
+		t.Errorf("got %d computations, want a miss after an update", calls)
+	}
+	if len(c.items) != 1 {
+		t.Errorf("got %d entries, want 1", len(c.items))
+	}
+}